@@ -0,0 +1,60 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import "fmt"
+
+// customAllocator holds the callbacks set by WithAllocator.
+type customAllocator struct {
+	callbacks C.lseco_allocator_t
+}
+
+// WithAllocator allocates the storage through the C callbacks in alloc,
+// via lseco_create_with_allocator, instead of malloc and mlock; for
+// example to place secrets in PKCS#11 HSM memory. Resize and
+// UnmarshalBinary reuse the same allocator.
+//
+// The library does not mlock custom memory: alloc.alloc must return
+// memory that is already locked in RAM and at least page-aligned, since
+// access is still revoked between operations. alloc.ctx is handed to C,
+// so it must not point to Go memory; pass a runtime/cgo.Handle to reach
+// Go state. Constructors return ErrInvalidAllocator if alloc.alloc or
+// alloc.free is nil.
+func WithAllocator(alloc C.lseco_allocator_t) Option {
+	return func(c *storageConfig) {
+		c.allocator = &customAllocator{callbacks: alloc}
+	}
+}
+
+// createHandle allocates a C region of size bytes as configured by
+// WithAllocator, WithPageGuard, and WithSGXEnclave.
+func (c *storageConfig) createHandle(size, alignment int) (C.lseco_handle_t, error) {
+	if c.sgxEnclave && !SGXAvailable() {
+		return nil, fmt.Errorf("%w (enclave %d)", ErrSGXUnavailable, c.sgxEnclaveID)
+	}
+
+	var callbacks *C.lseco_allocator_t
+	if a := c.allocator; a != nil {
+		if a.callbacks.alloc == nil || a.callbacks.free == nil {
+			return nil, ErrInvalidAllocator
+		}
+		callbacks = &a.callbacks
+	}
+
+	var handle C.lseco_handle_t
+	switch {
+	case c.pageGuard:
+		handle = C.lseco_create_guarded(C.size_t(size), C.size_t(alignment), callbacks)
+	case callbacks != nil:
+		handle = C.lseco_create_with_allocator(C.size_t(size), C.size_t(alignment), callbacks)
+	default:
+		handle = C.lseco_create_aligned(C.size_t(size), C.size_t(alignment))
+	}
+
+	if handle == nil {
+		return nil, fmt.Errorf("failed to create secure storage")
+	}
+	return handle, nil
+}
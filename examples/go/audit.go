@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// AuditHandler receives an event for every successful access to a
+// SecureStorage. Handlers only ever see metadata, never secret content:
+// the time of the access and the frame of the code that made it.
+//
+// Handlers are called while the storage is locked, so they must not call
+// back into it.
+type AuditHandler interface {
+	// OnStore is called after content is written (Store and its
+	// variants, StoreNamed, Write, UnmarshalBinary, UnmarshalJSON).
+	OnStore(at time.Time, caller runtime.Frame)
+
+	// OnRetrieve is called after content is read out (Retrieve and its
+	// variants, RetrieveNamed, RetrieveBuffer, Read, MarshalBinary,
+	// MarshalJSON).
+	OnRetrieve(at time.Time, caller runtime.Frame)
+
+	// OnDestroy is called when the C allocation is freed.
+	OnDestroy(at time.Time, caller runtime.Frame)
+}
+
+// RotationAuditHandler is implemented by AuditHandlers that want Rotate
+// reported as its own event. Rotations are reported to other handlers as
+// OnStore.
+type RotationAuditHandler interface {
+	// OnRotate is called after Rotate has replaced the content.
+	OnRotate(at time.Time, caller runtime.Frame)
+}
+
+// WithAuditHandler reports every access to the storage to h. By default
+// nothing is recorded.
+func WithAuditHandler(h AuditHandler) Option {
+	return func(c *storageConfig) {
+		c.audit = h
+	}
+}
+
+// LogAuditHandler is an AuditHandler writing one structured log record
+// per event.
+type LogAuditHandler struct {
+	logger *slog.Logger
+}
+
+// NewLogAuditHandler returns a LogAuditHandler writing JSON lines to w.
+func NewLogAuditHandler(w io.Writer) *LogAuditHandler {
+	return &LogAuditHandler{logger: slog.New(slog.NewJSONHandler(w, nil))}
+}
+
+func (h *LogAuditHandler) OnStore(at time.Time, caller runtime.Frame) {
+	h.log("store", at, caller)
+}
+
+func (h *LogAuditHandler) OnRetrieve(at time.Time, caller runtime.Frame) {
+	h.log("retrieve", at, caller)
+}
+
+func (h *LogAuditHandler) OnRotate(at time.Time, caller runtime.Frame) {
+	h.log("rotate", at, caller)
+}
+
+func (h *LogAuditHandler) OnDestroy(at time.Time, caller runtime.Frame) {
+	h.log("destroy", at, caller)
+}
+
+func (h *LogAuditHandler) log(event string, at time.Time, caller runtime.Frame) {
+	h.logger.LogAttrs(context.Background(), slog.LevelInfo, "lseco audit",
+		slog.String("event", event),
+		slog.Time("at", at),
+		slog.String("func", caller.Function),
+		slog.String("file", caller.File),
+		slog.Int("line", caller.Line),
+	)
+}
+
+// storageOp identifies an access reported by record.
+type storageOp int
+
+const (
+	opStore storageOp = iota
+	opRetrieve
+	opRotate
+	opDestroy
+)
+
+func (op storageOp) String() string {
+	switch op {
+	case opStore:
+		return "store"
+	case opRetrieve:
+		return "retrieve"
+	case opRotate:
+		return "rotate"
+	default:
+		return "destroy"
+	}
+}
+
+// record reports one successful access to the metrics and AuditHandler
+// configured for the storage, if any.
+func (s *SecureStorage) record(op storageOp) {
+	s.cfg.metrics.observe(op)
+
+	if s.cfg.audit == nil {
+		return
+	}
+	at, caller := time.Now(), auditCaller()
+	switch op {
+	case opStore:
+		s.cfg.audit.OnStore(at, caller)
+	case opRetrieve:
+		s.cfg.audit.OnRetrieve(at, caller)
+	case opRotate:
+		if h, ok := s.cfg.audit.(RotationAuditHandler); ok {
+			h.OnRotate(at, caller)
+		} else {
+			s.cfg.audit.OnStore(at, caller)
+		}
+	case opDestroy:
+		s.cfg.audit.OnDestroy(at, caller)
+	}
+}
+
+// auditCaller returns the first frame outside the SecureStorage methods
+// and constructors, so events point at user code regardless of which
+// wrapper (StoreCtx, TryStore, ...) was called.
+func auditCaller() runtime.Frame {
+	var pcs [16]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs[:])])
+
+	self, more := frames.Next()
+	// self.Function is "<pkg>.auditCaller"
+	pkg := strings.TrimSuffix(self.Function, "auditCaller")
+	for more {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+		if !strings.HasPrefix(frame.Function, pkg+"(*SecureStorage).") &&
+			!strings.HasPrefix(frame.Function, pkg+"NewSecureStorage") {
+			return frame
+		}
+	}
+	return runtime.Frame{}
+}
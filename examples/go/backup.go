@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Backup layout: magic, version (1 byte), scrypt salt, payload length
+// (uint32), the payload in the MarshalBinary layout, then a CRC-32 (IEEE)
+// of everything before it. Magic, version, and salt are authenticated as
+// additional data. The CRC only catches accidental corruption; tampering
+// is detected by AES-256-GCM.
+const (
+	backupMagic      = "LSBK"
+	backupVersion    = 1
+	backupSaltSize   = 16
+	backupPrefixSize = len(backupMagic) + 1 + backupSaltSize
+	backupHeaderSize = backupPrefixSize + 4
+)
+
+// scrypt cost parameters for the backup key, as recommended for
+// interactive use in 2017; a backup is rare enough to afford them.
+const (
+	backupScryptN = 1 << 15
+	backupScryptR = 8
+	backupScryptP = 1
+)
+
+// WithBackupPassphrase sets the passphrase protecting Backup output and
+// read by Restore. The AES-256 key is derived from it with scrypt and a
+// random salt per backup. The passphrase is copied and, like the
+// WithSerializationKey key, lives on the Go heap.
+func WithBackupPassphrase(passphrase []byte) Option {
+	return func(c *storageConfig) {
+		c.backupPassphrase = append([]byte(nil), passphrase...)
+	}
+}
+
+// Backup writes the stored content to w, sealed with AES-256-GCM under a
+// key derived from the WithBackupPassphrase passphrase, for maintenance
+// such as moving secrets between hosts; Restore reads it back. The output
+// is versioned, length-prefixed, and ends in a CRC-32 checksum. As in
+// MarshalBinary, plaintext never leaves the C buffer, and only the
+// content written by Store or Write is included.
+//
+// It returns ErrNoBackupPassphrase if no passphrase was set. Backing up
+// counts as one retrieval for WithMaxRetrievals.
+func (s *SecureStorage) Backup(w io.Writer) error {
+	s.mu.Lock()
+	out, err := s.backupLocked()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+func (s *SecureStorage) backupLocked() ([]byte, error) {
+	if s.cfg.backupPassphrase == nil {
+		return nil, ErrNoBackupPassphrase
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, backupPrefixSize, backupHeaderSize)
+	copy(prefix, backupMagic)
+	prefix[len(backupMagic)] = backupVersion
+	salt := prefix[len(backupMagic)+1:]
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	aead, err := newBackupAEAD(s.cfg.backupPassphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := s.sealLocked(aead, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	out := binary.BigEndian.AppendUint32(prefix, uint32(len(payload)))
+	out = append(out, payload...)
+	out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out))
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return out, nil
+}
+
+// Restore reads a backup written by Backup from r and decrypts it
+// directly into a new storage of size bytes created with opts, which
+// must include the same WithBackupPassphrase. Only the backup itself is
+// read from r.
+//
+// It returns ErrNoBackupPassphrase if opts set no passphrase,
+// ErrBackupCorrupted if the checksum does not match, and
+// ErrDataTruncation if size cannot hold the backed-up content.
+func Restore(r io.Reader, size int, opts ...Option) (*SecureStorage, error) {
+	cfg := newStorageConfig(opts)
+	if cfg.backupPassphrase == nil {
+		return nil, ErrNoBackupPassphrase
+	}
+
+	header := make([]byte, backupHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read backup header: %w", err)
+	}
+	if !bytes.Equal(header[:len(backupMagic)], []byte(backupMagic)) {
+		return nil, fmt.Errorf("not an lseco backup")
+	}
+	if v := header[len(backupMagic)]; v != backupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", v)
+	}
+
+	// Bound the payload by size (plus the GCM nonce and tag), so a
+	// corrupted length cannot make us allocate without limit.
+	n := int(binary.BigEndian.Uint32(header[backupPrefixSize:]))
+	if maxPayload := serializationHeaderSize + 12 + size + 16; n > maxPayload {
+		return nil, fmt.Errorf("%w: backup of %d bytes does not fit size %d", ErrDataTruncation, n, size)
+	}
+	rest := make([]byte, n+4)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	payload, sum := rest[:n], binary.BigEndian.Uint32(rest[n:])
+	crc := crc32.Update(crc32.ChecksumIEEE(header), crc32.IEEETable, payload)
+	if crc != sum {
+		return nil, ErrBackupCorrupted
+	}
+
+	prefix := header[:backupPrefixSize]
+	aead, err := newBackupAEAD(cfg.backupPassphrase, prefix[len(backupMagic)+1:])
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewSecureStorage(size, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.openLocked(aead, payload, prefix)
+	if err == nil && s.size != size {
+		err = s.resizeLocked(size)
+	}
+	if err != nil {
+		s.abandonLocked()
+		return nil, err
+	}
+	s.record(opStore)
+	return s, nil
+}
+
+// newBackupAEAD derives the backup key from passphrase and salt.
+func newBackupAEAD(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, backupScryptN, backupScryptR, backupScryptP, serializationKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive backup key: %w", err)
+	}
+	defer SecureZero(key)
+	return newAEAD(key)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBackupRestore(t *testing.T) {
+	passphrase := WithBackupPassphrase([]byte("correct horse"))
+	data := []byte("api token")
+	s := newTestStorage(t, 32, passphrase)
+	mustStore(t, s, data)
+
+	var backup bytes.Buffer
+	if err := s.Backup(&backup); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if bytes.Contains(backup.Bytes(), data) {
+		t.Fatal("backup contains the plaintext")
+	}
+
+	restored, err := Restore(bytes.NewReader(backup.Bytes()), 32, passphrase)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	t.Cleanup(restored.Destroy)
+	requireContent(t, restored, data)
+
+	if _, err := Restore(bytes.NewReader(backup.Bytes()), 32); !errors.Is(err, ErrNoBackupPassphrase) {
+		t.Fatalf("Restore without a passphrase = %v, want ErrNoBackupPassphrase", err)
+	}
+	wrong := WithBackupPassphrase([]byte("battery staple"))
+	if _, err := Restore(bytes.NewReader(backup.Bytes()), 32, wrong); err == nil {
+		t.Fatal("Restore with the wrong passphrase succeeded")
+	}
+	corrupted := bytes.Clone(backup.Bytes())
+	corrupted[len(corrupted)-1] ^= 1
+	if _, err := Restore(bytes.NewReader(corrupted), 32, passphrase); !errors.Is(err, ErrBackupCorrupted) {
+		t.Fatalf("Restore of a corrupted backup = %v, want ErrBackupCorrupted", err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// NewSecureStorageFromBase64 decodes encoded, as found in config files
+// and environment variables, into a new storage of size bytes created
+// with opts. Both the standard and the URL-safe alphabet are accepted,
+// with or without padding. The secret is decoded into a locked
+// SecureBuffer that is zeroed before returning, so it never lands on the
+// Go heap; encoded itself is an immutable string and cannot be zeroed.
+//
+// It returns ErrInvalidEncoding if encoded is not valid base64, and
+// ErrDecodedSizeMismatch if the decoded secret is longer than size.
+func NewSecureStorageFromBase64(encoded string, size int, opts ...Option) (*SecureStorage, error) {
+	encoded = strings.TrimRight(strings.TrimSpace(encoded), "=")
+	enc := base64.RawStdEncoding
+	if strings.ContainsAny(encoded, "-_") {
+		enc = base64.RawURLEncoding
+	}
+
+	n := enc.DecodedLen(len(encoded))
+	if n == 0 {
+		return nil, fmt.Errorf("%w: empty input", ErrInvalidEncoding)
+	}
+	staging, err := NewSecureBuffer(n)
+	if err != nil {
+		return nil, err
+	}
+	defer staging.Close()
+
+	n, err = enc.Decode(staging.Bytes(), []byte(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	if n > size {
+		return nil, fmt.Errorf("%w: %d > %d", ErrDecodedSizeMismatch, n, size)
+	}
+
+	return NewSecureStorageFromBytes(staging.Bytes()[:n], size, opts...)
+}
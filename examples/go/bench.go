@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/snowmerak/lseco/examples/go/lsecobench"
+)
+
+// runBenchmarks runs the lsecobench suite against SecureStorage and
+// prints the results. It is invoked by `go run . bench`.
+func runBenchmarks() {
+	newStorage := func(size int) (*SecureStorage, error) {
+		return NewSecureStorage(size)
+	}
+	pool, err := NewSecureStoragePool(4, lsecobench.StorageSize)
+	if err != nil {
+		fmt.Printf("Failed to create pool: %v\n", err)
+		return
+	}
+	defer pool.Close()
+
+	benchmarks := []struct {
+		name string
+		fn   func(b *testing.B)
+	}{
+		{"Store", func(b *testing.B) { lsecobench.BenchmarkStore(b, newStorage) }},
+		{"Retrieve", func(b *testing.B) { lsecobench.BenchmarkRetrieve(b, newStorage) }},
+		{"CreateDestroy", func(b *testing.B) { lsecobench.BenchmarkCreateDestroy(b, newStorage) }},
+		{"Pool", func(b *testing.B) { lsecobench.BenchmarkPool(b, pool) }},
+		{"ConstantTimeCopy", func(b *testing.B) { lsecobench.BenchmarkConstantTimeCopy(b, newStorage) }},
+	}
+	for _, bm := range benchmarks {
+		r := testing.Benchmark(bm.fn)
+		if r.N == 0 {
+			fmt.Printf("%-16s FAILED\n", bm.name)
+			continue
+		}
+		fmt.Printf("%-16s %s %s\n", bm.name, r, r.MemString())
+	}
+}
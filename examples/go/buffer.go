@@ -0,0 +1,155 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// SecureBuffer holds plaintext in mlock-ed C memory instead of on the
+// garbage-collected heap, so it is never moved or copied by the GC and
+// never swapped to disk. It implements io.ReadCloser; Close zeroes,
+// unlocks, and frees the memory.
+type SecureBuffer struct {
+	mu   sync.Mutex
+	ptr  unsafe.Pointer
+	size int
+	off  int // read cursor
+}
+
+// NewSecureBuffer allocates a zero-filled locked buffer of size bytes.
+func NewSecureBuffer(size int) (*SecureBuffer, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid buffer size %d", size)
+	}
+
+	ptr := C.lseco_buffer_create(C.size_t(size))
+	if ptr == nil {
+		return nil, fmt.Errorf("failed to create secure buffer")
+	}
+
+	b := &SecureBuffer{
+		ptr:  ptr,
+		size: size,
+	}
+	runtime.SetFinalizer(b, finalizeBuffer)
+
+	return b, nil
+}
+
+// RetrieveBuffer retrieves length bytes straight into a new SecureBuffer,
+// so the plaintext never touches the Go heap. The caller must Close it.
+func (s *SecureStorage) RetrieveBuffer(length int) (*SecureBuffer, error) {
+	key, err := s.lockWithDataKey(false)
+	if err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+	defer key.Close()
+
+	b, err := s.retrieveBufferLocked(length, key)
+	if err != nil {
+		return nil, err
+	}
+	s.record(opRetrieve)
+	return b, nil
+}
+
+func (s *SecureStorage) retrieveBufferLocked(length int, key *envelopeKey) (*SecureBuffer, error) {
+	if s.cfg.encoded() {
+		return s.retrieveDecodedLocked(length, key)
+	}
+	if length <= 0 || length > s.size {
+		return nil, fmt.Errorf("invalid length %d (max: %d)", length, s.size)
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	b, err := NewSecureBuffer(length)
+	if err != nil {
+		return nil, err
+	}
+
+	result := C.lseco_retrieve(s.handle, b.ptr, C.size_t(length))
+	if result != C.LSECO_SUCCESS {
+		b.Close()
+		return nil, resultError("retrieve", result)
+	}
+
+	s.countRetrievalLocked()
+	return b, nil
+}
+
+// Bytes returns a slice backed directly by the locked C memory, or nil
+// once the buffer is closed.
+//
+// The slice must not escape: do not retain it, append to it, or pass it
+// to anything that may keep a reference after Close. Using it after
+// Close is a use-after-free.
+func (b *SecureBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ptr == nil {
+		return nil
+	}
+	return unsafe.Slice((*byte)(b.ptr), b.size)
+}
+
+// Len returns the number of bytes not yet consumed by Read.
+func (b *SecureBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ptr == nil {
+		return 0
+	}
+	return b.size - b.off
+}
+
+// Read copies unread bytes into p, implementing io.Reader.
+func (b *SecureBuffer) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ptr == nil {
+		return 0, fmt.Errorf("read from closed secure buffer")
+	}
+	if b.off >= b.size {
+		return 0, io.EOF
+	}
+
+	n := copy(p, unsafe.Slice((*byte)(b.ptr), b.size)[b.off:])
+	b.off += n
+	return n, nil
+}
+
+// Close zeroes, unlocks, and frees the buffer. It is safe to call more
+// than once.
+func (b *SecureBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ptr != nil {
+		C.lseco_buffer_destroy(b.ptr, C.size_t(b.size))
+		b.ptr = nil
+		runtime.SetFinalizer(b, nil)
+	}
+	return nil
+}
+
+func finalizeBuffer(b *SecureBuffer) {
+	if b.ptr == nil {
+		return
+	}
+
+	warnLeak(nil, "lseco: SecureBuffer was garbage collected without Close", b.size)
+	b.Close()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SecureStorageBuilder constructs a SecureStorage through chained calls
+// instead of a long list of Option values:
+//
+//	s, err := NewSecureStorageBuilder().
+//		Size(256).
+//		Data(secret).
+//		WithTTL(5 * time.Minute).
+//		WithAuditHandler(h).
+//		Build()
+//
+// Settings are only checked by Build, which reports every invalid or
+// conflicting one at once. Integrity tags are always on, so there is no
+// setting for them. A builder is not safe for concurrent use, but Build
+// may be called more than once to make several storages.
+type SecureStorageBuilder struct {
+	size      int
+	alignment int
+	data      []byte
+	ttl       time.Duration
+	opts      []Option
+	errs      []error // from setters, reported by Build
+}
+
+// NewSecureStorageBuilder returns a builder with no settings.
+func NewSecureStorageBuilder() *SecureStorageBuilder {
+	return &SecureStorageBuilder{}
+}
+
+// Size sets the storage capacity in bytes. It defaults to len(data) when
+// Data is set.
+func (b *SecureStorageBuilder) Size(n int) *SecureStorageBuilder {
+	b.size = n
+	return b
+}
+
+// Alignment sets the alignment passed to NewSecureStorageAligned.
+func (b *SecureStorageBuilder) Alignment(n int) *SecureStorageBuilder {
+	b.alignment = n
+	return b
+}
+
+// Data sets the initial content, stored by Build. The slice is not
+// copied: keep it intact until Build returns, then zero it.
+func (b *SecureStorageBuilder) Data(data []byte) *SecureStorageBuilder {
+	b.data = data
+	return b
+}
+
+// WithTTL stores Data with StoreWithTTL, so it is wiped once ttl has
+// elapsed. It requires Data.
+func (b *SecureStorageBuilder) WithTTL(ttl time.Duration) *SecureStorageBuilder {
+	b.ttl = ttl
+	return b
+}
+
+// WithPreZero adds WithPreZero(true).
+func (b *SecureStorageBuilder) WithPreZero() *SecureStorageBuilder {
+	return b.WithOption(WithPreZero(true))
+}
+
+// WithLogger adds WithLogger(l).
+func (b *SecureStorageBuilder) WithLogger(l *slog.Logger) *SecureStorageBuilder {
+	return b.WithOption(WithLogger(l))
+}
+
+// WithMaxRetrievals adds WithMaxRetrievals(n).
+func (b *SecureStorageBuilder) WithMaxRetrievals(n int) *SecureStorageBuilder {
+	return b.WithOption(WithMaxRetrievals(n))
+}
+
+// WithRetrievalRateLimit adds WithRetrievalRateLimit(rps).
+func (b *SecureStorageBuilder) WithRetrievalRateLimit(rps float64) *SecureStorageBuilder {
+	return b.WithOption(WithRetrievalRateLimit(rps))
+}
+
+// WithSerializationKey adds WithSerializationKey(key). Build rejects a
+// key that is not 32 bytes.
+func (b *SecureStorageBuilder) WithSerializationKey(key []byte) *SecureStorageBuilder {
+	if len(key) != serializationKeySize {
+		b.errs = append(b.errs, fmt.Errorf("serialization key must be %d bytes, got %d", serializationKeySize, len(key)))
+	}
+	return b.WithOption(WithSerializationKey(key))
+}
+
+// WithAuditHandler adds WithAuditHandler(h).
+func (b *SecureStorageBuilder) WithAuditHandler(h AuditHandler) *SecureStorageBuilder {
+	return b.WithOption(WithAuditHandler(h))
+}
+
+// WithMetrics adds WithMetrics(reg).
+func (b *SecureStorageBuilder) WithMetrics(reg prometheus.Registerer) *SecureStorageBuilder {
+	return b.WithOption(WithMetrics(reg))
+}
+
+// WithMaxSnapshots adds WithMaxSnapshots(n).
+func (b *SecureStorageBuilder) WithMaxSnapshots(n int) *SecureStorageBuilder {
+	return b.WithOption(WithMaxSnapshots(n))
+}
+
+// WithOption adds any other Option, such as WithAllocator or WithTracer.
+func (b *SecureStorageBuilder) WithOption(opt Option) *SecureStorageBuilder {
+	b.opts = append(b.opts, opt)
+	return b
+}
+
+// Build validates the settings and creates the storage, storing Data if
+// it was set.
+func (b *SecureStorageBuilder) Build() (*SecureStorage, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+
+	size := b.size
+	if size == 0 {
+		size = len(b.data)
+	}
+
+	var s *SecureStorage
+	var err error
+	if b.alignment != 0 {
+		s, err = NewSecureStorageAligned(size, b.alignment, b.opts...)
+	} else {
+		s, err = NewSecureStorage(size, b.opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b.ttl > 0:
+		err = s.StoreWithTTL(b.data, b.ttl)
+	case len(b.data) > 0:
+		err = s.Store(b.data)
+	}
+	if err != nil {
+		s.Destroy()
+		return nil, err
+	}
+	return s, nil
+}
+
+// validate returns every problem with the settings, joined.
+func (b *SecureStorageBuilder) validate() error {
+	errs := append([]error(nil), b.errs...)
+	switch {
+	case b.size < 0:
+		errs = append(errs, fmt.Errorf("invalid size %d", b.size))
+	case b.size == 0 && len(b.data) == 0:
+		errs = append(errs, fmt.Errorf("size or data is required"))
+	case b.size > 0 && len(b.data) > b.size:
+		errs = append(errs, fmt.Errorf("data length %d exceeds size %d", len(b.data), b.size))
+	}
+	if b.alignment < 0 || b.alignment&(b.alignment-1) != 0 {
+		errs = append(errs, fmt.Errorf("%w: %d", ErrInvalidAlignment, b.alignment))
+	}
+	if b.ttl < 0 {
+		errs = append(errs, fmt.Errorf("ttl must be positive, got %v", b.ttl))
+	}
+	if b.ttl > 0 && len(b.data) == 0 {
+		errs = append(errs, fmt.Errorf("ttl requires data"))
+	}
+	return errors.Join(errs...)
+}
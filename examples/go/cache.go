@@ -0,0 +1,128 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SecureStorageCache keeps up to a fixed number of storages by key, such
+// as TLS client keys that a service loads over and over, evicting the
+// least recently used one when full. An entry is also dropped once its
+// TTL passes. Dropped storages, whether evicted, expired, replaced, or
+// removed, are wiped with Wipe but not destroyed: the caller that created
+// them remains responsible for Destroy. It is safe for concurrent use by
+// multiple goroutines.
+type SecureStorageCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // of *cacheEntry, most recently used first
+	entries  map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key       string
+	storage   *SecureStorage
+	expiresAt time.Time // zero means never
+}
+
+// NewSecureStorageCache returns an empty cache holding at most capacity
+// entries.
+func NewSecureStorageCache(capacity int) (*SecureStorageCache, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("cache capacity must be positive, got %d", capacity)
+	}
+	return &SecureStorageCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element, capacity),
+	}, nil
+}
+
+// Get returns the storage cached under key and marks it as recently
+// used. An expired entry is dropped and reported as missing.
+func (c *SecureStorageCache) Get(key string) (*SecureStorage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		c.dropLocked(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.storage, true
+}
+
+// Set caches s under key for ttl, or until evicted if ttl is zero,
+// replacing any previous entry. If the cache is full, the least recently
+// used entry is evicted.
+func (c *SecureStorageCache) Set(key string, s *SecureStorage, ttl time.Duration) error {
+	if s == nil || s.IsDestroyed() {
+		return fmt.Errorf("cannot cache a nil or destroyed storage")
+	}
+	if ttl < 0 {
+		return fmt.Errorf("ttl must not be negative, got %v", ttl)
+	}
+	entry := &cacheEntry{key: key, storage: s}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		if elem.Value.(*cacheEntry).storage == s {
+			elem.Value = entry
+			c.order.MoveToFront(elem)
+			return nil
+		}
+		c.dropLocked(elem)
+	}
+	for c.order.Len() >= c.capacity {
+		c.dropLocked(c.order.Back())
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	return nil
+}
+
+// Remove drops the entry for key, if any.
+func (c *SecureStorageCache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.dropLocked(elem)
+	}
+}
+
+// Len returns the number of cached entries, including expired ones not
+// yet dropped.
+func (c *SecureStorageCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// dropLocked wipes the storage in elem and removes it from the cache.
+func (c *SecureStorageCache) dropLocked(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	s := entry.storage
+	if s.IsDestroyed() {
+		return
+	}
+	if err := s.Wipe(); err != nil {
+		s.mu.RLock()
+		logger, size := s.cfg.logger, s.size
+		s.mu.RUnlock()
+		warnLeak(logger, "lseco: failed to wipe SecureStorage dropped from cache", size)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := NewSecureStorageCache(2)
+	if err != nil {
+		t.Fatalf("NewSecureStorageCache failed: %v", err)
+	}
+	storages := map[string]*SecureStorage{}
+	for _, key := range []string{"a", "b", "c"} {
+		s := newTestStorage(t, 16)
+		mustStore(t, s, []byte(key))
+		storages[key] = s
+	}
+
+	for _, key := range []string{"a", "b"} {
+		if err := c.Set(key, storages[key], 0); err != nil {
+			t.Fatalf("Set(%s) failed: %v", key, err)
+		}
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("Get(a) missed")
+	}
+	if err := c.Set("c", storages["c"], 0); err != nil {
+		t.Fatalf("Set(c) failed: %v", err)
+	}
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("least recently used entry b was not evicted")
+	}
+	if storages["b"].Used() != 0 {
+		t.Fatalf("evicted storage not wiped: Used() = %d", storages["b"].Used())
+	}
+	if got, ok := c.Get("a"); !ok || got != storages["a"] {
+		t.Fatal("recently used entry a was evicted")
+	}
+	requireContent(t, storages["a"], []byte("a"))
+	if c.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c, err := NewSecureStorageCache(4)
+	if err != nil {
+		t.Fatalf("NewSecureStorageCache failed: %v", err)
+	}
+	s := newTestStorage(t, 16)
+	mustStore(t, s, []byte("short-lived"))
+
+	if err := c.Set("k", s, 10*time.Millisecond); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("expired entry still returned")
+	}
+	if s.Used() != 0 {
+		t.Fatalf("expired storage not wiped: Used() = %d", s.Used())
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import "maps"
+
+// Clone duplicates the storage into a fresh allocation. The content is
+// copied C-to-C and never passes through the Go heap. The clone carries
+// over the stored length, cursors, named slots, options, retrieval count,
+// and TTL deadline, and gets its own finalizer; it must be destroyed
+// independently.
+//
+// Cloning counts as one retrieval of s, so it is refused once s has
+// expired or used up its retrievals. The clone shares the token bucket of
+// WithRetrievalRateLimit with s, so cloning does not buy extra rate.
+func (s *SecureStorage) Clone() (*SecureStorage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+	clone, err := s.cloneLocked(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	clone.limiter = s.limiter
+	if !s.expiresAt.IsZero() {
+		clone.expiresAt = s.expiresAt
+		expiry.schedule(clone, clone.expiresAt, clone.expiryGen)
+	}
+	s.countRetrievalLocked()
+
+	s.record(opRetrieve)
+	return clone, nil
+}
+
+// cloneLocked copies the content and cursors of s into a new storage
+// created with cfg. The TTL deadline is left to the caller.
+func (s *SecureStorage) cloneLocked(cfg storageConfig) (*SecureStorage, error) {
+	// The clone is tagged afresh, so corruption must be caught here.
+	if err := s.verifyLocked(); err != nil {
+		return nil, err
+	}
+
+	clone, err := newSecureStorage(s.size, s.alignment, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := C.lseco_copy_at(clone.handle, 0, s.handle, 0, C.size_t(s.size))
+	if result != C.LSECO_SUCCESS {
+		clone.Destroy()
+		return nil, resultError("clone", result)
+	}
+	if err := clone.retagLocked(); err != nil {
+		clone.Destroy()
+		return nil, err
+	}
+
+	clone.length = s.length
+	clone.plainLength = s.plainLength
+	clone.readOff = s.readOff
+	clone.writeOff = s.writeOff
+	clone.slots = s.slots.clone()
+	clone.retrievals = s.retrievals
+
+	return clone, nil
+}
+
+// clone returns a deep copy of the index.
+func (idx slotIndex) clone() slotIndex {
+	return slotIndex{
+		active: maps.Clone(idx.active),
+		free:   append([]namedSlot(nil), idx.free...),
+		end:    idx.end,
+	}
+}
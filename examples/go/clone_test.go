@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	s := newTestStorage(t, 64)
+	mustStore(t, s, []byte("original"))
+
+	clone, err := s.Clone()
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	t.Cleanup(clone.Destroy)
+	requireContent(t, clone, []byte("original"))
+
+	// The copies are independent.
+	mustStore(t, clone, []byte("changed!"))
+	requireContent(t, s, []byte("original"))
+}
+
+func TestCloneNamedSlots(t *testing.T) {
+	s := newTestStorage(t, 64)
+	if err := s.StoreNamed("pin", []byte("1234")); err != nil {
+		t.Fatalf("StoreNamed failed: %v", err)
+	}
+
+	clone, err := s.Clone()
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	t.Cleanup(clone.Destroy)
+
+	pin, err := clone.RetrieveNamed("pin")
+	if err != nil {
+		t.Fatalf("RetrieveNamed on clone failed: %v", err)
+	}
+	if string(pin) != "1234" {
+		t.Fatalf("RetrieveNamed(pin) = %q, want %q", pin, "1234")
+	}
+}
+
+func TestCloneCountsRetrieval(t *testing.T) {
+	s := newTestStorage(t, 32, WithMaxRetrievals(2))
+	mustStore(t, s, []byte("secret"))
+
+	clone, err := s.Clone()
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	t.Cleanup(clone.Destroy)
+
+	if got := s.RetrievalsRemaining(); got != 1 {
+		t.Fatalf("RetrievalsRemaining() after Clone = %d, want 1", got)
+	}
+	if got := clone.RetrievalsRemaining(); got != 2 {
+		t.Fatalf("clone RetrievalsRemaining() = %d, want 2", got)
+	}
+
+	if _, err := s.Retrieve(6); err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if _, err := s.Clone(); !errors.Is(err, ErrRetrievalLimitExceeded) {
+		t.Fatalf("Clone past the limit = %v, want ErrRetrievalLimitExceeded", err)
+	}
+}
+
+func TestCloneSharesRateLimit(t *testing.T) {
+	s := newTestStorage(t, 32, WithRetrievalRateLimit(0.001))
+	mustStore(t, s, []byte("secret"))
+
+	clone, err := s.Clone()
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	t.Cleanup(clone.Destroy)
+
+	if _, err := clone.Retrieve(6); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Fatalf("Retrieve on clone = %v, want ErrRateLimitExceeded", err)
+	}
+}
+
+func TestCloneCompressed(t *testing.T) {
+	data := bytes.Repeat([]byte("abc"), 50)
+	s := newTestStorage(t, 64, WithCompress(LZ4))
+	mustStore(t, s, data)
+
+	clone, err := s.Clone()
+	if err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	t.Cleanup(clone.Destroy)
+
+	if clone.Used() != len(data) {
+		t.Fatalf("clone Used() = %d, want %d", clone.Used(), len(data))
+	}
+	requireContent(t, clone, data)
+}
+
+func TestAtomicSwapKeepsOptions(t *testing.T) {
+	data := bytes.Repeat([]byte("old"), 50)
+	s := newTestStorage(t, 64, WithCompress(Gzip), WithMaxRetrievals(3))
+	mustStore(t, s, data)
+
+	old, err := s.AtomicSwap([]byte("new"))
+	if err != nil {
+		t.Fatalf("AtomicSwap failed: %v", err)
+	}
+	t.Cleanup(old.Destroy)
+
+	requireContent(t, old, data)
+	requireContent(t, s, []byte("new"))
+	if got := s.RetrievalsRemaining(); got != 1 {
+		t.Fatalf("RetrievalsRemaining() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Codec converts stored content to and from a wire format for Marshal
+// and Unmarshal. Encode is handed a view of the locked memory and must
+// not retain it; Decode's result is zeroed once it has been stored.
+type Codec interface {
+	Encode(plaintext []byte) ([]byte, error)
+	Decode(encoded []byte) ([]byte, error)
+}
+
+// Marshal encodes the stored content with codec, which reads it straight
+// from the locked C buffer. Unless codec encrypts, the result holds the
+// secret on the Go heap, and the caller should zero it after use.
+//
+// Marshaling counts as one retrieval.
+func (s *SecureStorage) Marshal(codec Codec) ([]byte, error) {
+	if err := checkRaw(s); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.length == 0 {
+		return nil, fmt.Errorf("no data stored")
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	err := s.withViewLocked(func(view []byte) error {
+		var err error
+		out, err = codec.Encode(view[:s.length:s.length])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return out, nil
+}
+
+// Unmarshal decodes data produced by Marshal with the same codec and
+// stores the result like Store.
+func (s *SecureStorage) Unmarshal(codec Codec, data []byte) error {
+	plaintext, err := codec.Decode(data)
+	if err != nil {
+		return err
+	}
+	defer SecureZero(plaintext)
+
+	return s.Store(plaintext)
+}
+
+// RawCodec passes the content through unchanged, for transports such as
+// gRPC that carry raw bytes.
+type RawCodec struct{}
+
+// Encode returns a copy of plaintext.
+func (RawCodec) Encode(plaintext []byte) ([]byte, error) {
+	return bytes.Clone(plaintext), nil
+}
+
+// Decode returns a copy of encoded, so zeroing it leaves the input intact.
+func (RawCodec) Decode(encoded []byte) ([]byte, error) {
+	return bytes.Clone(encoded), nil
+}
+
+// Base64Codec uses standard, padded base64, as JSON APIs expect.
+type Base64Codec struct{}
+
+// Encode returns plaintext in base64.
+func (Base64Codec) Encode(plaintext []byte) ([]byte, error) {
+	out := make([]byte, base64.StdEncoding.EncodedLen(len(plaintext)))
+	base64.StdEncoding.Encode(out, plaintext)
+	return out, nil
+}
+
+// Decode reverses Encode.
+func (Base64Codec) Decode(encoded []byte) ([]byte, error) {
+	out := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	n, err := base64.StdEncoding.Decode(out, encoded)
+	if err != nil {
+		SecureZero(out)
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// HexCodec uses lowercase hexadecimal, which is convenient in debuggers.
+type HexCodec struct{}
+
+// Encode returns plaintext in hex.
+func (HexCodec) Encode(plaintext []byte) ([]byte, error) {
+	out := make([]byte, hex.EncodedLen(len(plaintext)))
+	hex.Encode(out, plaintext)
+	return out, nil
+}
+
+// Decode reverses Encode.
+func (HexCodec) Decode(encoded []byte) ([]byte, error) {
+	out := make([]byte, hex.DecodedLen(len(encoded)))
+	n, err := hex.Decode(out, encoded)
+	if err != nil {
+		SecureZero(out)
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+// aesGCMCodec seals the content as nonce || AES-256-GCM ciphertext.
+type aesGCMCodec struct {
+	aead cipher.AEAD
+}
+
+// AESGCMCodec returns a Codec that encrypts under the 32-byte AES-256
+// key, with a random nonce per Encode, so the output is safe to store or
+// send. The key slice is not retained.
+func AESGCMCodec(key []byte) (Codec, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return aesGCMCodec{aead: aead}, nil
+}
+
+func (c aesGCMCodec) Encode(plaintext []byte) ([]byte, error) {
+	out := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := rand.Read(out); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(out, out, plaintext, nil), nil
+}
+
+func (c aesGCMCodec) Decode(encoded []byte) ([]byte, error) {
+	if len(encoded) < c.aead.NonceSize()+c.aead.Overhead() {
+		return nil, fmt.Errorf("encoded data too short")
+	}
+	nonce, sealed := encoded[:c.aead.NonceSize()], encoded[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
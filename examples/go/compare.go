@@ -0,0 +1,154 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Compare reports whether s and other hold the same content, using a
+// constant-time comparison in the C layer. Neither secret is copied to
+// the Go heap. Storages whose stored lengths differ are never equal; the
+// lengths themselves are not treated as secret.
+//
+// The comparison counts as one retrieval from each storage, so it fails
+// like Retrieve once either has expired, used up its retrievals, hit its
+// rate limit, or failed its integrity check.
+func (s *SecureStorage) Compare(other *SecureStorage) (bool, error) {
+	if err := checkRaw(s, other); err != nil {
+		return false, err
+	}
+
+	unlock := lockPair(s, other)
+	defer unlock()
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return false, err
+	}
+	if other != s {
+		if err := other.checkRetrievalLocked(); err != nil {
+			return false, err
+		}
+	}
+
+	equal, err := s.compareLocked(other)
+	if err != nil {
+		return false, err
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	if other != s {
+		other.countRetrievalLocked()
+		other.record(opRetrieve)
+	}
+	return equal, nil
+}
+
+func (s *SecureStorage) compareLocked(other *SecureStorage) (bool, error) {
+	if s.length != other.length {
+		return false, nil
+	}
+	if s.length == 0 {
+		return s.handle != nil && other.handle != nil, nil
+	}
+
+	var equal C.int
+	result := C.lseco_compare(s.handle, other.handle, C.size_t(s.length), &equal)
+	if result != C.LSECO_SUCCESS {
+		return false, resultError("compare", result)
+	}
+
+	return equal == 1, nil
+}
+
+// DiffRange is a half-open range [Start, End) of byte positions.
+type DiffRange struct {
+	Start, End int
+}
+
+// Diff returns the ranges of positions at which s and v2 differ, in
+// ascending order, e.g. to confirm that only the trailing nonce of a key
+// changed across a rotation. Identical content yields no ranges. The
+// per-byte comparison runs in constant time in the C layer and reports
+// only positions, never byte values, so the result is safe to log.
+//
+// It returns ErrSizeMismatch if the Used values of s and v2 differ, so
+// rotation checks can tell a short write from a changed value.
+func (s *SecureStorage) Diff(v2 *SecureStorage) ([]DiffRange, error) {
+	if err := checkRaw(s, v2); err != nil {
+		return nil, err
+	}
+
+	unlock := lockPair(s, v2)
+	defer unlock()
+
+	if s.handle == nil || v2.handle == nil {
+		return nil, fmt.Errorf("storage is destroyed")
+	}
+	if s.length != v2.length {
+		return nil, fmt.Errorf("%w: %d != %d", ErrSizeMismatch, s.length, v2.length)
+	}
+	if s.length == 0 {
+		return nil, nil
+	}
+
+	changed := make([]byte, s.length)
+	result := C.lseco_diff(s.handle, v2.handle, C.size_t(s.length), (*C.uchar)(&changed[0]))
+	if result != C.LSECO_SUCCESS {
+		return nil, resultError("diff", result)
+	}
+
+	var ranges []DiffRange
+	for i := 0; i < len(changed); i++ {
+		if changed[i] == 0 {
+			continue
+		}
+		start := i
+		for i < len(changed) && changed[i] != 0 {
+			i++
+		}
+		ranges = append(ranges, DiffRange{Start: start, End: i})
+	}
+	return ranges, nil
+}
+
+// SecureCompare reports whether a holds exactly b, such as an expected
+// hash loaded from a database, using the constant-time comparison in the
+// C layer, so the secret is never copied to the Go heap. b is pinned by
+// the cgo call for its duration and is not retained. As with Compare, a
+// length mismatch is reported as false without comparing content. The
+// comparison counts as one retrieval from a, with the same checks as
+// Compare.
+func SecureCompare(a *SecureStorage, b []byte) (bool, error) {
+	if err := checkRaw(a); err != nil {
+		return false, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.handle == nil {
+		return false, fmt.Errorf("storage is destroyed")
+	}
+	if err := a.checkRetrievalLocked(); err != nil {
+		return false, err
+	}
+
+	equal := a.length == len(b)
+	if equal && len(b) > 0 {
+		var result C.int
+		code := C.lseco_compare_bytes(a.handle, unsafe.Pointer(&b[0]), C.size_t(len(b)), &result)
+		if code != C.LSECO_SUCCESS {
+			return false, resultError("compare", code)
+		}
+		equal = result == 1
+	}
+
+	a.countRetrievalLocked()
+	a.record(opRetrieve)
+	return equal, nil
+}
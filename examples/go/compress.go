@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// CompressionAlgorithm selects the compression applied by WithCompress.
+type CompressionAlgorithm int
+
+const (
+	// Gzip uses DEFLATE in a gzip stream (compress/gzip).
+	Gzip CompressionAlgorithm = iota + 1
+	// Zstd uses a Zstandard frame (github.com/klauspost/compress/zstd).
+	Zstd
+	// LZ4 uses a raw LZ4 block (github.com/pierrec/lz4/v4).
+	LZ4
+)
+
+func (a CompressionAlgorithm) String() string {
+	switch a {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	case LZ4:
+		return "lz4"
+	default:
+		return fmt.Sprintf("CompressionAlgorithm(%d)", int(a))
+	}
+}
+
+// errCompressedTooLarge is returned by fixedWriter once its buffer is
+// full.
+var errCompressedTooLarge = errors.New("compressed data exceeds storage size")
+
+// WithCompress compresses content with algorithm on Store and decompresses
+// it on Retrieve, so large, redundant secrets such as certificate chains
+// or authorized_keys files lock fewer pages: the storage size only has to
+// fit the compressed form. Compression and decompression write into
+// locked staging buffers, and RetrieveBuffer hands the decompressed
+// content back without it touching the Go heap.
+//
+// Store and its variants, Retrieve, RetrieveAll, and RetrieveBuffer are
+// transparent, and Used reports the decompressed length; methods that
+// work on the raw bytes, such as ReadAt, Write, and Compare, return
+// ErrEncodedContent.
+// Gzip and Zstd keep part of their input in internal Go buffers while
+// they run, which lseco cannot zero; LZ4 works block to block and has no
+// such state, so prefer it for the most sensitive content.
+func WithCompress(algorithm CompressionAlgorithm) Option {
+	return func(c *storageConfig) {
+		c.compression = algorithm
+	}
+}
+
+// storeCompressedLocked compresses data into a locked staging buffer and
+// stores the result.
+func (s *SecureStorage) storeCompressedLocked(data []byte) error {
+	staging, err := NewSecureBuffer(s.size)
+	if err != nil {
+		return err
+	}
+	defer staging.Close()
+
+	n, err := compressInto(s.cfg.compression, staging.Bytes(), data)
+	if err != nil {
+		return fmt.Errorf("failed to compress with %v: %w", s.cfg.compression, err)
+	}
+
+	oldLength := s.length
+	if err := s.storeRawLocked(staging.Bytes()[:n]); err != nil {
+		return err
+	}
+	// Store leaves bytes past the new length in place; clear what is left
+	// of the previous content.
+	if oldLength > n {
+		if err := s.wipeLocked(n, oldLength-n); err != nil {
+			return err
+		}
+	}
+	s.plainLength = len(data)
+	return nil
+}
+
+// decompressLocked decompresses the content into a new locked buffer of
+// the decompressed length.
+func (s *SecureStorage) decompressLocked() (*SecureBuffer, error) {
+	if s.length == 0 {
+		return nil, fmt.Errorf("no data stored")
+	}
+	out, err := NewSecureBuffer(s.plainLength)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.withViewLocked(func(view []byte) error {
+		return decompressInto(s.cfg.compression, out.Bytes(), view[:s.length:s.length])
+	})
+	if err != nil {
+		out.Close()
+		return nil, fmt.Errorf("failed to decompress with %v: %w", s.cfg.compression, err)
+	}
+	return out, nil
+}
+
+// decodeLocked decompresses or decrypts the content, whichever its
+// encoding, into a new locked buffer of the plaintext length. key is the
+// data key of an envelope, or nil.
+func (s *SecureStorage) decodeLocked(key *envelopeKey) (*SecureBuffer, error) {
+	if s.cfg.keyWrapper != nil {
+		return s.openEnvelopeLocked(key)
+	}
+	return s.decompressLocked()
+}
+
+// retrieveDecodedLocked is retrieveBufferLocked for a WithCompress or
+// envelope encrypted storage, with length counting plaintext bytes.
+func (s *SecureStorage) retrieveDecodedLocked(length int, key *envelopeKey) (*SecureBuffer, error) {
+	if length <= 0 || length > s.plainLength {
+		return nil, fmt.Errorf("invalid length %d (max: %d)", length, s.plainLength)
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	plain, err := s.decodeLocked(key)
+	if err != nil {
+		return nil, err
+	}
+	if length < plain.Len() {
+		b, err := NewSecureBuffer(length)
+		if err == nil {
+			copy(b.Bytes(), plain.Bytes()[:length])
+		}
+		plain.Close()
+		if err != nil {
+			return nil, err
+		}
+		plain = b
+	}
+
+	s.countRetrievalLocked()
+	return plain, nil
+}
+
+// compressInto compresses src into dst and returns the compressed length.
+func compressInto(algorithm CompressionAlgorithm, dst, src []byte) (int, error) {
+	if algorithm == LZ4 {
+		var c lz4.Compressor
+		n, err := c.CompressBlock(src, dst)
+		if err == nil && n == 0 {
+			// CompressBlock reports data that does not fit as 0 bytes.
+			err = errCompressedTooLarge
+		}
+		return n, err
+	}
+
+	w := &fixedWriter{buf: dst}
+	var zw io.WriteCloser
+	switch algorithm {
+	case Gzip:
+		zw = gzip.NewWriter(w)
+	case Zstd:
+		enc, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return 0, err
+		}
+		zw = enc
+	default:
+		return 0, fmt.Errorf("unsupported compression algorithm %v", algorithm)
+	}
+	if _, err := zw.Write(src); err != nil {
+		zw.Close()
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	return w.n, nil
+}
+
+// decompressInto decompresses src into dst, which must be exactly the
+// decompressed length.
+func decompressInto(algorithm CompressionAlgorithm, dst, src []byte) error {
+	var r io.Reader
+	switch algorithm {
+	case LZ4:
+		n, err := lz4.UncompressBlock(src, dst)
+		if err == nil && n != len(dst) {
+			err = fmt.Errorf("decompressed %d bytes, want %d", n, len(dst))
+		}
+		return err
+	case Gzip:
+		zr, err := gzip.NewReader(bytes.NewReader(src))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	case Zstd:
+		zr, err := zstd.NewReader(bytes.NewReader(src), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return fmt.Errorf("unsupported compression algorithm %v", algorithm)
+	}
+	_, err := io.ReadFull(r, dst)
+	return err
+}
+
+// fixedWriter writes into a fixed buffer and fails instead of growing,
+// so compressed output never spills onto the Go heap.
+type fixedWriter struct {
+	buf []byte
+	n   int
+}
+
+func (w *fixedWriter) Write(p []byte) (int, error) {
+	if len(p) > len(w.buf)-w.n {
+		return 0, errCompressedTooLarge
+	}
+	w.n += copy(w.buf[w.n:], p)
+	return len(p), nil
+}
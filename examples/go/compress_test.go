@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("-----BEGIN CERTIFICATE-----\n"), 32)
+	for _, algorithm := range []CompressionAlgorithm{Gzip, Zstd, LZ4} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			s := newTestStorage(t, 256, WithCompress(algorithm))
+			mustStore(t, s, data)
+
+			if s.Used() != len(data) {
+				t.Fatalf("Used() = %d, want %d", s.Used(), len(data))
+			}
+			requireContent(t, s, data)
+
+			got, err := s.Retrieve(10)
+			if err != nil {
+				t.Fatalf("Retrieve failed: %v", err)
+			}
+			if !bytes.Equal(got, data[:10]) {
+				t.Fatalf("Retrieve(10) = %q, want %q", got, data[:10])
+			}
+		})
+	}
+}
+
+func TestCompressTooLarge(t *testing.T) {
+	s := newTestStorage(t, 16, WithCompress(LZ4))
+	random := []byte("q8#Zt!mX0@vL2&pR5^wN7*yB")
+
+	if err := s.Store(random); err == nil {
+		t.Fatal("Store of incompressible data larger than the storage succeeded")
+	}
+}
+
+func TestCompressRotateShorter(t *testing.T) {
+	data := bytes.Repeat([]byte("abcd"), 64)
+	s := newTestStorage(t, 128, WithCompress(Gzip))
+	mustStore(t, s, data)
+
+	if err := s.Rotate(data[:20]); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	requireContent(t, s, data[:20])
+}
+
+func TestCompressRejectsRawAccess(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 100)
+	s := newTestStorage(t, 128, WithCompress(LZ4))
+	mustStore(t, s, data)
+	raw := newTestStorage(t, 128)
+	mustStore(t, raw, []byte("raw"))
+	nonce := make([]byte, 24)
+	conn, peer := net.Pipe()
+	peer.Close()
+	t.Cleanup(func() { conn.Close() })
+	signer, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"ReadAt", func() error { _, err := s.ReadAt(make([]byte, 4), 0); return err }()},
+		{"Read", func() error { _, err := s.Read(make([]byte, 4)); return err }()},
+		{"Write", func() error { _, err := s.Write([]byte("y")); return err }()},
+		{"Compare", func() error { _, err := s.Compare(raw); return err }()},
+		{"SecureCompare", func() error { _, err := SecureCompare(s, data); return err }()},
+		{"CopyTo", raw.CopyTo(s)},
+		{"ConstantTimeCopy", s.ConstantTimeCopy(raw)},
+		{"Shrink", s.Shrink(1)},
+		{"Truncate", s.Truncate(64)},
+		{"Hash", func() error { _, err := s.Hash(sha256.New()); return err }()},
+		{"Checksum", func() error { _, err := s.Checksum(); return err }()},
+		{"Sign", func() error { _, err := s.Sign(signer, crypto.SHA256); return err }()},
+		{"ExportLocked", s.ExportLocked(func([]byte) error { return nil })},
+		{"ExportPublicKey", func() error { _, err := s.ExportPublicKey(); return err }()},
+		{"Marshal", func() error { _, err := s.Marshal(RawCodec{}); return err }()},
+		{"Split", func() error { _, err := s.Split(2); return err }()},
+		{"Combine", func() error { _, err := Combine([]*SecureStorage{raw, s}); return err }()},
+		{"KeyDerivation", func() error { _, err := s.KeyDerivation([]byte("label"), 32); return err }()},
+		{"XOR", s.XOR(raw)},
+		{"XOR mask", raw.XOR(s)},
+		{"ObfuscateXOR", s.ObfuscateXOR([]byte{1})},
+		{"MergeSecrets", func() error { _, err := MergeSecrets(raw, s, MergeOpConcat, 0); return err }()},
+		{"FillRandom", s.FillRandom(rand.Reader)},
+		{"XChaCha20Encrypt", func() error { _, err := s.XChaCha20Encrypt(raw, nonce); return err }()},
+		{"XChaCha20Encrypt key", func() error { _, err := raw.XChaCha20Encrypt(s, nonce); return err }()},
+		{"StoreNamed", s.StoreNamed("slot", []byte("y"))},
+		{"RetrieveNamed", func() error { _, err := s.RetrieveNamed("slot"); return err }()},
+		{"ReadFromConn", s.ReadFromConn(conn, 1)},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, ErrEncodedContent) {
+			t.Errorf("%s = %v, want ErrEncodedContent", tt.name, tt.err)
+		}
+	}
+	requireContent(t, s, data)
+	requireContent(t, raw, []byte("raw"))
+}
+
+// TestCompressSealedFormats checks the exports that keep content sealed
+// with its encoding: they round-trip into a storage compressed the same
+// way and are refused by a raw one.
+func TestCompressSealedFormats(t *testing.T) {
+	key := bytes.Repeat([]byte{8}, serializationKeySize)
+	passphrase := WithBackupPassphrase([]byte("correct horse"))
+	data := bytes.Repeat([]byte("sealed"), 30)
+	s := newTestStorage(t, 128, WithCompress(LZ4), passphrase)
+	mustStore(t, s, data)
+
+	t.Run("Backup", func(t *testing.T) {
+		var backup bytes.Buffer
+		if err := s.Backup(&backup); err != nil {
+			t.Fatalf("Backup failed: %v", err)
+		}
+		restored, err := Restore(bytes.NewReader(backup.Bytes()), 128, WithCompress(LZ4), passphrase)
+		if err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		t.Cleanup(restored.Destroy)
+		requireContent(t, restored, data)
+
+		if _, err := Restore(bytes.NewReader(backup.Bytes()), 128, passphrase); !errors.Is(err, ErrEncodedContent) {
+			t.Fatalf("Restore into a raw storage = %v, want ErrEncodedContent", err)
+		}
+	})
+
+	t.Run("PEM", func(t *testing.T) {
+		block, err := s.MarshalPEM("LSECO SECRET", key)
+		if err != nil {
+			t.Fatalf("MarshalPEM failed: %v", err)
+		}
+		decoded := newTestStorage(t, 1, WithCompress(LZ4))
+		if err := decoded.UnmarshalPEM(block, key); err != nil {
+			t.Fatalf("UnmarshalPEM failed: %v", err)
+		}
+		requireContent(t, decoded, data)
+
+		raw := newTestStorage(t, 1)
+		if err := raw.UnmarshalPEM(block, key); !errors.Is(err, ErrEncodedContent) {
+			t.Fatalf("UnmarshalPEM into a raw storage = %v, want ErrEncodedContent", err)
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secret.bin")
+		if err := s.SaveToFile(path, key); err != nil {
+			t.Fatalf("SaveToFile failed: %v", err)
+		}
+		loaded, err := LoadFromFile(path, 128, key, WithCompress(LZ4))
+		if err != nil {
+			t.Fatalf("LoadFromFile failed: %v", err)
+		}
+		t.Cleanup(loaded.Destroy)
+		requireContent(t, loaded, data)
+
+		if _, err := LoadFromFile(path, 128, key); !errors.Is(err, ErrEncodedContent) {
+			t.Fatalf("LoadFromFile into a raw storage = %v, want ErrEncodedContent", err)
+		}
+	})
+
+	t.Run("PKCS8", func(t *testing.T) {
+		exported, err := s.ExportPKCS8(key)
+		if err != nil {
+			t.Fatalf("ExportPKCS8 failed: %v", err)
+		}
+		unsealed := newTestStorage(t, 1, WithCompress(LZ4))
+		if err := unsealed.Unseal(key, exported); err != nil {
+			t.Fatalf("Unseal failed: %v", err)
+		}
+		requireContent(t, unsealed, data)
+
+		raw := newTestStorage(t, 1)
+		if err := raw.Unseal(key, exported); !errors.Is(err, ErrEncodedContent) {
+			t.Fatalf("Unseal into a raw storage = %v, want ErrEncodedContent", err)
+		}
+	})
+
+	requireContent(t, s, data)
+}
+
+// Fingerprint never reads the content, so it works on encoded storages.
+func TestCompressFingerprint(t *testing.T) {
+	s := newTestStorage(t, 128, WithCompress(Gzip))
+	mustStore(t, s, bytes.Repeat([]byte("id"), 50))
+
+	fp := s.Fingerprint()
+	if len(fp) != 8 {
+		t.Fatalf("Fingerprint() = %q, want 8 hex digits", fp)
+	}
+	if again := s.Fingerprint(); again != fp {
+		t.Fatalf("Fingerprint() changed from %q to %q", fp, again)
+	}
+}
+
+func TestCompressSerialization(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, serializationKeySize)
+	data := bytes.Repeat([]byte("token"), 40)
+	s := newTestStorage(t, 128, WithCompress(Zstd), WithSerializationKey(key))
+	mustStore(t, s, data)
+
+	encoded, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	decoded := newTestStorage(t, 1, WithCompress(Zstd), WithSerializationKey(key))
+	if err := decoded.UnmarshalBinary(encoded); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	requireContent(t, decoded, data)
+
+	raw := newTestStorage(t, 1, WithSerializationKey(key))
+	if err := raw.UnmarshalBinary(encoded); !errors.Is(err, ErrEncodedContent) {
+		t.Fatalf("UnmarshalBinary into a raw storage = %v, want ErrEncodedContent", err)
+	}
+}
+
+func TestCompressSealUnseal(t *testing.T) {
+	key := bytes.Repeat([]byte{9}, serializationKeySize)
+	data := bytes.Repeat([]byte("chain"), 40)
+	s := newTestStorage(t, 128, WithCompress(LZ4))
+	mustStore(t, s, data)
+
+	sealed, err := s.Seal(key)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if s.Used() != 0 {
+		t.Fatalf("Used() after Seal = %d, want 0", s.Used())
+	}
+	if err := s.Unseal(key, sealed); err != nil {
+		t.Fatalf("Unseal failed: %v", err)
+	}
+	requireContent(t, s, data)
+}
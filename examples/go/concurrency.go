@@ -0,0 +1,65 @@
+package main
+
+// The methods below implement WithConcurrencyDetector. Lock, TryLock,
+// and RLock mark the storage as in use before waiting for the lock, and
+// the unlocks clear the mark once the method is done, so a second
+// goroutine calling into the storage meanwhile panics instead of
+// queueing behind the first.
+
+// Lock marks the storage as in use, then takes the write lock.
+func (m *storageMutex) Lock() {
+	m.enter()
+	m.RWMutex.Lock()
+}
+
+// TryLock is Lock without waiting. It reports whether the lock was taken.
+func (m *storageMutex) TryLock() bool {
+	m.enter()
+	if !m.RWMutex.TryLock() {
+		m.leave()
+		return false
+	}
+	return true
+}
+
+// RLock marks the storage as in use, then takes the read lock.
+func (m *storageMutex) RLock() {
+	m.enter()
+	m.RWMutex.RLock()
+}
+
+// RUnlock clears the in-use mark, then releases the read lock.
+func (m *storageMutex) RUnlock() {
+	m.leave()
+	m.RWMutex.RUnlock()
+}
+
+// lockInternal takes the write lock without marking the storage as in
+// use, for background work such as an expiry or a group wipe that may
+// legitimately overlap a caller's method. It is released with
+// unlockInternal.
+func (m *storageMutex) lockInternal() {
+	m.RWMutex.Lock()
+}
+
+// unlockInternal is Unlock for lockInternal.
+func (m *storageMutex) unlockInternal() {
+	g := m.takePendingWipe()
+	m.RWMutex.Unlock()
+	g.wipeAll()
+}
+
+// enter marks the storage as in use, panicking if it already is: two
+// goroutines are inside its methods at once.
+func (m *storageMutex) enter() {
+	if m.detect && !m.inUse.CompareAndSwap(false, true) {
+		panic("lseco: concurrent unsynchronized access to SecureStorage")
+	}
+}
+
+// leave clears the mark set by enter.
+func (m *storageMutex) leave() {
+	if m.detect {
+		m.inUse.Store(false)
+	}
+}
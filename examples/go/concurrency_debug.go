@@ -0,0 +1,18 @@
+//go:build lseco_debug
+
+package main
+
+// WithConcurrencyDetector makes the storage panic when two goroutines
+// call its methods at the same time, the way sync.Mutex reports misuse.
+// It is meant for code that should only ever use a storage from one
+// goroutine at a time: every method marks the storage as in use with an
+// atomic flag before taking the storage's lock and clears it on return,
+// so an overlapping call panics instead of silently waiting. Background
+// work by lseco itself, such as a StoreWithTTL expiry or a group wipe,
+// does not count. The flag is cheap but not free, so the option is only
+// available when built with -tags lseco_debug.
+func WithConcurrencyDetector() Option {
+	return func(c *storageConfig) {
+		c.detectConcurrency = true
+	}
+}
@@ -0,0 +1,41 @@
+//go:build lseco_debug
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConcurrencyDetector(t *testing.T) {
+	s := newTestStorage(t, 32, WithConcurrencyDetector())
+	mustStore(t, s, []byte("secret"))
+
+	// Holding the lock stands in for another goroutine inside a method.
+	s.mu.Lock()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Used() during another call did not panic")
+			}
+		}()
+		s.Used()
+	}()
+	s.mu.Unlock()
+
+	requireContent(t, s, []byte("secret"))
+}
+
+func TestConcurrencyDetectorIgnoresExpiry(t *testing.T) {
+	s := newTestStorage(t, 32, WithConcurrencyDetector())
+	if err := s.StoreWithTTL([]byte("secret"), time.Millisecond); err != nil {
+		t.Fatalf("StoreWithTTL failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Used() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("storage was not wiped after its TTL")
+		}
+	}
+}
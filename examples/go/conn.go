@@ -0,0 +1,97 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+)
+
+// ReadFromConn reads exactly n bytes from conn into the locked memory,
+// replacing the content as Store would. Connections that expose their
+// socket through syscall.Conn, such as *net.TCPConn and *net.UnixConn,
+// are drained with a C-level recv loop, so the bytes never pass through a
+// Go buffer. Other connections, including *tls.Conn, are read straight
+// into the mapped region instead; the plaintext still lands only in
+// locked memory, although the connection may buffer it internally.
+//
+// Read deadlines set on conn are honored. On error, including a deadline
+// or the peer closing early, whatever was read is zeroed and the storage
+// is left empty.
+func (s *SecureStorage) ReadFromConn(conn net.Conn, n int) error {
+	if err := checkRaw(s); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n <= 0 || n > s.size {
+		return fmt.Errorf("invalid length %d (max: %d)", n, s.size)
+	}
+	// As in Store, bytes past n are kept and retagged.
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
+
+	var err error
+	if sc, ok := conn.(syscall.Conn); ok {
+		err = s.recvLocked(sc, n)
+	} else {
+		err = s.withViewLocked(func(view []byte) error {
+			_, err := io.ReadFull(conn, view[:n])
+			return err
+		})
+	}
+	if err != nil {
+		return s.discardPartialLocked(n, err)
+	}
+
+	s.length = n
+	s.readOff = 0
+	s.writeOff = n
+	s.clearExpiryLocked()
+	if err := s.retagLocked(); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
+
+// recvLocked fills the first n bytes with lseco_recv_at, waiting on the
+// runtime poller whenever the socket runs dry.
+func (s *SecureStorage) recvLocked(sc syscall.Conn, n int) error {
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	received := 0
+	var recvErr error
+	err = raw.Read(func(fd uintptr) bool {
+		var got C.size_t
+		result, errno := C.lseco_recv_at(s.handle, C.size_t(received), C.intptr_t(fd), C.size_t(n-received), &got)
+		received += int(got)
+		switch {
+		case result == C.LSECO_SUCCESS:
+		case result != C.LSECO_ERR_IO:
+			recvErr = resultError("recv", result)
+		case errors.Is(errno, syscall.EAGAIN):
+			return false // wait for readability, then resume
+		case errno == nil:
+			recvErr = io.ErrUnexpectedEOF
+		default:
+			recvErr = fmt.Errorf("recv failed: %w", errno)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return recvErr
+}
@@ -0,0 +1,61 @@
+package main
+
+import "context"
+
+// StoreCtx stores data like Store, but returns ctx.Err() as soon as ctx
+// is done.
+//
+// A cgo call cannot be interrupted, so a store abandoned because of ctx
+// keeps running in the background until the C layer returns. The caller
+// must not modify or zero data until that happens; if that is not
+// acceptable, check ctx before calling Store instead.
+func (s *SecureStorage) StoreCtx(ctx context.Context, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.store(ctx, data)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RetrieveCtx retrieves data like Retrieve, but returns ctx.Err() as
+// soon as ctx is done. If the retrieve is abandoned, the bytes it
+// eventually copies out are zeroed instead of being handed back.
+func (s *SecureStorage) RetrieveCtx(ctx context.Context, length int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type retrieveResult struct {
+		data []byte
+		err  error
+	}
+
+	done := make(chan retrieveResult)
+	abandoned := make(chan struct{})
+	go func() {
+		data, err := s.retrieve(ctx, length)
+		select {
+		case done <- retrieveResult{data, err}:
+		case <-abandoned:
+			SecureZero(data)
+		}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		close(abandoned)
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import "fmt"
+
+// CopyTo replaces the content of dst with the content of s, copying it
+// C-to-C between the two locked regions so it never touches the Go heap.
+// It returns ErrDestinationTooSmall, without modifying dst, if dst.Size()
+// is less than s.Used(). Both storages stay locked for the whole copy.
+//
+// The copy counts as one retrieval from s for WithMaxRetrievals and
+// leaves dst as a Store would; named slots are not copied.
+func (s *SecureStorage) CopyTo(dst *SecureStorage) error {
+	if err := checkRaw(s, dst); err != nil {
+		return err
+	}
+
+	unlock := lockPair(s, dst)
+	defer unlock()
+
+	if s.length == 0 {
+		return fmt.Errorf("source storage is empty")
+	}
+	if s.length > dst.size {
+		return fmt.Errorf("%w: need %d bytes, have %d", ErrDestinationTooSmall, s.length, dst.size)
+	}
+	if s == dst {
+		return nil
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return err
+	}
+	// dst keeps its bytes past s.Used() and is retagged.
+	if err := dst.verifyLocked(); err != nil {
+		return err
+	}
+
+	result := C.lseco_copy_at(dst.handle, 0, s.handle, 0, C.size_t(s.length))
+	if result != C.LSECO_SUCCESS {
+		return resultError("copy", result)
+	}
+	s.countRetrievalLocked()
+
+	dst.length = s.length
+	dst.readOff = 0
+	dst.writeOff = s.length
+	dst.clearExpiryLocked()
+	if err := dst.retagLocked(); err != nil {
+		return err
+	}
+
+	s.record(opRetrieve)
+	dst.record(opStore)
+	return nil
+}
+
+// ConstantTimeCopy replaces the content of s with the content of src like
+// CopyTo, but through lseco_ct_copy, whose running time depends only on
+// the length and never on the bytes copied, e.g. when moving key material
+// during blinding. It returns ErrDestinationTooSmall, without modifying
+// s, if s.Size() is less than src.Used(). Its timing is checked by
+// lsecobench.BenchmarkConstantTimeCopy.
+//
+// The copy counts as one retrieval from src for WithMaxRetrievals.
+func (s *SecureStorage) ConstantTimeCopy(src *SecureStorage) error {
+	if err := checkRaw(s, src); err != nil {
+		return err
+	}
+
+	unlock := lockPair(s, src)
+	defer unlock()
+
+	if src.length == 0 {
+		return fmt.Errorf("source storage is empty")
+	}
+	if src.length > s.size {
+		return fmt.Errorf("%w: need %d bytes, have %d", ErrDestinationTooSmall, src.length, s.size)
+	}
+	if s == src {
+		return nil
+	}
+	if err := src.checkRetrievalLocked(); err != nil {
+		return err
+	}
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
+
+	result := C.lseco_ct_copy(s.handle, src.handle, C.size_t(src.length))
+	if result != C.LSECO_SUCCESS {
+		return resultError("constant-time copy", result)
+	}
+	src.countRetrievalLocked()
+
+	s.length = src.length
+	s.readOff = 0
+	s.writeOff = src.length
+	s.clearExpiryLocked()
+	if err := s.retagLocked(); err != nil {
+		return err
+	}
+
+	src.record(opRetrieve)
+	s.record(opStore)
+	return nil
+}
+
+// AppendTo appends the content of s to the end of dst's content, C-to-C
+// like CopyTo, e.g. to join a key and an IV held in separate storages.
+// It returns ErrInsufficientSpace, without modifying dst, if
+// dst.Size()-dst.Used() is less than s.Used(). s is left unchanged.
+//
+// The append counts as one retrieval from s for WithMaxRetrievals and
+// moves dst's write cursor to the end of the new content.
+func (s *SecureStorage) AppendTo(dst *SecureStorage) error {
+	if err := checkRaw(s, dst); err != nil {
+		return err
+	}
+
+	unlock := lockPair(s, dst)
+	defer unlock()
+
+	if s.length == 0 {
+		return fmt.Errorf("source storage is empty")
+	}
+	if avail := dst.size - dst.length; s.length > avail {
+		return fmt.Errorf("%w: need %d bytes, have %d", ErrInsufficientSpace, s.length, avail)
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return err
+	}
+	if err := dst.verifyLocked(); err != nil {
+		return err
+	}
+
+	n := s.length
+	result := C.lseco_copy_at(dst.handle, C.size_t(dst.length), s.handle, 0, C.size_t(n))
+	if result != C.LSECO_SUCCESS {
+		return resultError("append", result)
+	}
+	s.countRetrievalLocked()
+
+	dst.length += n
+	dst.writeOff = dst.length
+	if err := dst.retagLocked(); err != nil {
+		return err
+	}
+
+	s.record(opRetrieve)
+	dst.record(opStore)
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/snowmerak/lseco/examples/go/lsecobench"
+)
+
+func TestConstantTimeCopy(t *testing.T) {
+	src := newTestStorage(t, 32)
+	mustStore(t, src, []byte("blinding factor"))
+	dst := newTestStorage(t, 32)
+
+	if err := dst.ConstantTimeCopy(src); err != nil {
+		t.Fatalf("ConstantTimeCopy failed: %v", err)
+	}
+	requireContent(t, dst, []byte("blinding factor"))
+
+	small := newTestStorage(t, 4)
+	if err := small.ConstantTimeCopy(src); !errors.Is(err, ErrDestinationTooSmall) {
+		t.Fatalf("ConstantTimeCopy into a smaller storage = %v, want ErrDestinationTooSmall", err)
+	}
+}
+
+func BenchmarkConstantTimeCopy(b *testing.B) {
+	lsecobench.BenchmarkConstantTimeCopy(b, func(size int) (*SecureStorage, error) {
+		return NewSecureStorage(size)
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// DrainTo copies the first min(len(dst), Len()) bytes of the content into
+// dst and returns how many were copied, then wipes the storage like Wipe,
+// for secrets read exactly once such as one-time passwords. The wipe
+// happens before DrainTo returns, whether or not dst was large enough or
+// the copy succeeded, so no copy remains in locked memory even if the
+// caller panics while using dst. dst itself is ordinary memory: zero it
+// when done.
+//
+// Draining counts as one retrieval for WithMaxRetrievals. An error from
+// the wipe is returned only if the copy succeeded.
+func (s *SecureStorage) DrainTo(dst []byte) (n int, err error) {
+	key, keyErr := s.lockWithDataKey(false)
+	if keyErr != nil {
+		// The wipe below still has to happen.
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+	defer key.Close()
+
+	if s.handle == nil {
+		return 0, fmt.Errorf("storage is destroyed")
+	}
+	defer func() {
+		if wipeErr := s.wipeAllLocked(); wipeErr != nil {
+			if err == nil {
+				err = wipeErr
+			}
+			return
+		}
+		s.clearExpiryLocked()
+	}()
+	if keyErr != nil {
+		return 0, keyErr
+	}
+
+	n = min(len(dst), s.usedLocked())
+	if n == 0 {
+		return 0, nil
+	}
+	if err := s.drainLocked(dst[:n], key); err != nil {
+		return 0, err
+	}
+	s.record(opRetrieve)
+	return n, nil
+}
+
+// drainLocked fills dst from the start of the content as one retrieval.
+func (s *SecureStorage) drainLocked(dst []byte, key *envelopeKey) error {
+	if s.cfg.encoded() {
+		plain, err := s.retrieveDecodedLocked(len(dst), key)
+		if err != nil {
+			return err
+		}
+		copy(dst, plain.Bytes())
+		plain.Close()
+		return nil
+	}
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return err
+	}
+	result := C.lseco_retrieve(s.handle, unsafe.Pointer(&dst[0]), C.size_t(len(dst)))
+	if result != C.LSECO_SUCCESS {
+		return resultError("drain", result)
+	}
+	s.countRetrievalLocked()
+	return nil
+}
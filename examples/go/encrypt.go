@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+// encryptionKeyInfo is the HKDF context for Encrypt keys.
+const encryptionKeyInfo = "lseco encrypt v1"
+
+// Encrypt returns the stored content sealed with AES-256-GCM, for keeping
+// in a database or etcd, and leaves the storage intact, unlike Seal.
+// additionalData is authenticated but not encrypted, and must be passed
+// again to DecryptInto.
+//
+// The key is derived from a random seed that lives in locked memory and
+// is never exported, so only the same storage can decrypt the result,
+// and only until it is destroyed. Use Seal or MarshalBinary for
+// ciphertext that must outlive the process.
+//
+// Encrypting counts as one retrieval for WithMaxRetrievals.
+func (s *SecureStorage) Encrypt(additionalData []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+	aead, err := s.encryptionAEADLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.sealLocked(aead, additionalData)
+	if err != nil {
+		return nil, err
+	}
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return out, nil
+}
+
+// DecryptInto decrypts ciphertext produced by Encrypt on this storage,
+// with the same additionalData, directly into a new C buffer of the
+// encrypted size, which replaces the current one.
+func (s *SecureStorage) DecryptInto(ciphertext, additionalData []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	aead, err := s.encryptionAEADLocked()
+	if err != nil {
+		return err
+	}
+	if err := s.openLocked(aead, ciphertext, additionalData); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
+
+// encryptionAEADLocked returns the cipher for Encrypt and DecryptInto,
+// creating the storage's seed on first use.
+func (s *SecureStorage) encryptionAEADLocked() (cipher.AEAD, error) {
+	if s.encryptionSeed == nil {
+		seed, err := NewSecureBuffer(sha256.Size)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := rand.Read(seed.Bytes()); err != nil {
+			seed.Close()
+			return nil, fmt.Errorf("failed to generate encryption seed: %w", err)
+		}
+		s.encryptionSeed = seed
+	}
+
+	key, err := hkdf.Key(sha256.New, s.encryptionSeed.Bytes(), nil, encryptionKeyInfo, serializationKeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer SecureZero(key)
+	return newAEAD(key)
+}
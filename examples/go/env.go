@@ -0,0 +1,71 @@
+package main
+
+/*
+#include <stdlib.h>
+#include <string.h>
+
+#ifdef _WIN32
+#define lseco_environ _environ
+#else
+extern char** environ;
+#define lseco_environ environ
+#endif
+
+// Overwrites the value of every "name=value" entry in environ with NULs,
+// in place, so it no longer shows up in /proc/self/environ. The entries
+// themselves are left for unsetenv to remove.
+static void lseco_zero_env(const char* name) {
+    size_t n = strlen(name);
+    for (char** e = lseco_environ; e != NULL && *e != NULL; e++) {
+        if (strncmp(*e, name, n) != 0 || (*e)[n] != '=') {
+            continue;
+        }
+        for (volatile char* p = *e + n + 1; *p != '\0'; p++) {
+            *p = '\0';
+        }
+    }
+}
+*/
+import "C"
+import (
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// LoadFromEnv moves the secret in the environment variable key into a new
+// storage sized to fit, created with opts, and removes it from the
+// environment: the value is overwritten in place in the C environ block,
+// which is what /proc/self/environ shows for variables inherited at
+// startup, and then unset. It returns ErrEnvVarNotSet if key is not set.
+//
+// Copies made before the call, by the parent process or by code that
+// already read the variable, are out of reach.
+func LoadFromEnv(key string, opts ...Option) (*SecureStorage, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEnvVarNotSet, key)
+	}
+	if value == "" {
+		return nil, fmt.Errorf("environment variable %s is empty", key)
+	}
+
+	data := []byte(value)
+	s, err := NewSecureStorageFromBytes(data, len(data), opts...)
+	SecureZero(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Go's own copy of inherited variables shares the environ memory, so
+	// the value must be stored before it is zeroed.
+	name := C.CString(key)
+	C.lseco_zero_env(name)
+	C.free(unsafe.Pointer(name))
+
+	if err := os.Unsetenv(key); err != nil {
+		s.Destroy()
+		return nil, fmt.Errorf("failed to unset %s: %w", key, err)
+	}
+	return s, nil
+}
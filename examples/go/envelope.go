@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+)
+
+// dataKeySize is the length of an envelope data key (AES-256).
+const dataKeySize = 32
+
+// keyWrapper is the hook a key management integration installs in
+// storageConfig for envelope encryption. The AWS KMS one lives in
+// kms_aws.go, behind the lseco_awskms build tag, so default builds do not
+// link the AWS SDK at all.
+type keyWrapper interface {
+	// generateDataKey writes a new data key into dst, which is
+	// dataKeySize bytes of locked memory, and returns the key encrypted
+	// under the master key.
+	generateDataKey(dst []byte) (encrypted []byte, err error)
+	// decryptDataKey writes the plaintext of an encrypted data key into
+	// dst, which is dataKeySize bytes of locked memory.
+	decryptDataKey(encrypted, dst []byte) error
+}
+
+// Envelope layout in the C buffer: encrypted data key length (uint16),
+// the encrypted data key, the GCM nonce, then the content sealed with
+// AES-256-GCM under the data key, with the encrypted data key as
+// additional data.
+const envelopeKeyLengthSize = 2
+
+// envelopeKey is a plaintext data key in locked memory together with its
+// encrypted form. It is obtained from the key wrapper before s.mu is
+// taken, so KMS round trips never hold the storage lock or keep its pages
+// mapped; only the local AES-GCM step runs under s.mu.
+type envelopeKey struct {
+	plain     *SecureBuffer
+	encrypted []byte
+}
+
+// Close zeroes and frees the plaintext data key. It is a no-op on a nil
+// key, so callers can defer it unconditionally.
+func (k *envelopeKey) Close() {
+	if k != nil {
+		k.plain.Close()
+	}
+}
+
+// generateEnvelopeKey asks the key wrapper for a fresh data key, or
+// returns nil if c does not use envelope encryption.
+func (c *storageConfig) generateEnvelopeKey() (*envelopeKey, error) {
+	if c.keyWrapper == nil {
+		return nil, nil
+	}
+	plain, err := NewSecureBuffer(dataKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := c.keyWrapper.generateDataKey(plain.Bytes())
+	if err == nil && (len(encrypted) == 0 || len(encrypted) > 0xffff) {
+		err = fmt.Errorf("invalid encrypted data key length %d", len(encrypted))
+	}
+	if err != nil {
+		plain.Close()
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	return &envelopeKey{plain: plain, encrypted: encrypted}, nil
+}
+
+// decryptEnvelopeKey asks the key wrapper for the plaintext of encrypted.
+func (c *storageConfig) decryptEnvelopeKey(encrypted []byte) (*envelopeKey, error) {
+	plain, err := NewSecureBuffer(dataKeySize)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.keyWrapper.decryptDataKey(encrypted, plain.Bytes()); err != nil {
+		plain.Close()
+		return nil, fmt.Errorf("failed to decrypt data key: %w", err)
+	}
+	return &envelopeKey{plain: plain, encrypted: encrypted}, nil
+}
+
+// lockWithDataKey takes s.mu, with TryLock if try is set, and returns the
+// decrypted data key of the current envelope, or nil if s is not envelope
+// encrypted or a retrieval would be refused anyway. The KMS round trip
+// runs with s.mu released; if the content changes meanwhile, the new key
+// is fetched instead. On success s.mu is held and the caller must unlock
+// it and close the key; on error s.mu is not held.
+func (s *SecureStorage) lockWithDataKey(try bool) (*envelopeKey, error) {
+	var key *envelopeKey
+	for {
+		if try {
+			if !s.mu.TryLock() {
+				key.Close()
+				return nil, ErrLocked
+			}
+		} else {
+			s.mu.Lock()
+		}
+		if s.cfg.keyWrapper == nil {
+			return nil, nil
+		}
+
+		encrypted, err := s.encryptedKeyLocked()
+		if err != nil {
+			s.mu.Unlock()
+			key.Close()
+			return nil, err
+		}
+		if encrypted == nil {
+			key.Close()
+			return nil, nil
+		}
+		if key != nil && bytes.Equal(key.encrypted, encrypted) {
+			return key, nil
+		}
+		s.mu.Unlock()
+		key.Close()
+
+		if key, err = s.cfg.decryptEnvelopeKey(encrypted); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// encryptedKeyLocked copies the encrypted data key out of the envelope,
+// or returns nil if there is no content or it may not be retrieved.
+func (s *SecureStorage) encryptedKeyLocked() ([]byte, error) {
+	if s.length == 0 || s.retrievableLocked() != nil {
+		return nil, nil
+	}
+	if err := s.verifyLocked(); err != nil {
+		return nil, err
+	}
+
+	var encrypted []byte
+	err := s.withViewLocked(func(view []byte) error {
+		envelope := view[:s.length:s.length]
+		header := envelopeKeyLengthSize + int(binary.BigEndian.Uint16(envelope))
+		if header > len(envelope) {
+			return fmt.Errorf("malformed envelope")
+		}
+		// A copy, so the wrapper never holds a reference into C memory.
+		encrypted = bytes.Clone(envelope[envelopeKeyLengthSize:header])
+		return nil
+	})
+	return encrypted, err
+}
+
+// storeEnvelopeLocked encrypts data under key, a fresh data key from
+// generateEnvelopeKey, and stores the envelope.
+func (s *SecureStorage) storeEnvelopeLocked(data []byte, key *envelopeKey) error {
+	if s.cfg.compression != 0 {
+		return fmt.Errorf("WithCompress cannot be combined with envelope encryption")
+	}
+	if key == nil {
+		return fmt.Errorf("no data key for envelope encryption")
+	}
+	aead, err := newAEAD(key.plain.Bytes())
+	if err != nil {
+		return err
+	}
+
+	n := envelopeKeyLengthSize + len(key.encrypted) + aead.NonceSize() + len(data) + aead.Overhead()
+	if n > s.size {
+		return fmt.Errorf("envelope of %d bytes exceeds storage size %d", n, s.size)
+	}
+	staging, err := NewSecureBuffer(n)
+	if err != nil {
+		return err
+	}
+	defer staging.Close()
+
+	out := staging.Bytes()
+	binary.BigEndian.PutUint16(out, uint16(len(key.encrypted)))
+	header := envelopeKeyLengthSize + copy(out[envelopeKeyLengthSize:], key.encrypted)
+	nonce := out[header : header+aead.NonceSize()]
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	aead.Seal(out[header+len(nonce):header+len(nonce)], nonce, data, key.encrypted)
+
+	oldLength := s.length
+	if err := s.storeRawLocked(out); err != nil {
+		return err
+	}
+	// As in storeCompressedLocked, clear what is left of the previous
+	// content.
+	if oldLength > n {
+		if err := s.wipeLocked(n, oldLength-n); err != nil {
+			return err
+		}
+	}
+	s.plainLength = len(data)
+	return nil
+}
+
+// openEnvelopeLocked decrypts the envelope under key, its data key from
+// lockWithDataKey, into a new locked buffer of the plaintext length.
+func (s *SecureStorage) openEnvelopeLocked(key *envelopeKey) (*SecureBuffer, error) {
+	if s.length == 0 {
+		return nil, fmt.Errorf("no data stored")
+	}
+	if key == nil {
+		return nil, fmt.Errorf("no data key for envelope decryption")
+	}
+	aead, err := newAEAD(key.plain.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	out, err := NewSecureBuffer(s.plainLength)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.withViewLocked(func(view []byte) error {
+		envelope := view[:s.length:s.length]
+		header := envelopeKeyLengthSize + len(key.encrypted)
+		if len(envelope) != header+aead.NonceSize()+s.plainLength+aead.Overhead() {
+			return fmt.Errorf("malformed envelope")
+		}
+		// The encrypted data key is the additional data, so a key for
+		// other content fails to open.
+		nonce := envelope[header : header+aead.NonceSize()]
+		_, err := aead.Open(out.Bytes()[:0], nonce, envelope[header+len(nonce):], key.encrypted)
+		return err
+	})
+	if err != nil {
+		out.Close()
+		return nil, err
+	}
+	return out, nil
+}
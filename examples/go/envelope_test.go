@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeKeyWrapper "encrypts" data keys by XOR with a fixed mask and checks
+// that the storage lock is never held while it is called.
+type fakeKeyWrapper struct {
+	t       *testing.T
+	storage atomic.Pointer[SecureStorage]
+	calls   atomic.Int32
+}
+
+const fakeKeyMask = 0x5a
+
+func (w *fakeKeyWrapper) checkUnlocked() {
+	w.calls.Add(1)
+	s := w.storage.Load()
+	if s == nil {
+		return
+	}
+	if !s.mu.TryLock() {
+		w.t.Error("key wrapper called with the storage lock held")
+		return
+	}
+	s.mu.Unlock()
+}
+
+func (w *fakeKeyWrapper) generateDataKey(dst []byte) ([]byte, error) {
+	w.checkUnlocked()
+	if _, err := rand.Read(dst); err != nil {
+		return nil, err
+	}
+	encrypted := make([]byte, len(dst))
+	for i, b := range dst {
+		encrypted[i] = b ^ fakeKeyMask
+	}
+	return encrypted, nil
+}
+
+func (w *fakeKeyWrapper) decryptDataKey(encrypted, dst []byte) error {
+	w.checkUnlocked()
+	if len(encrypted) != len(dst) {
+		return errors.New("bad encrypted key")
+	}
+	for i, b := range encrypted {
+		dst[i] = b ^ fakeKeyMask
+	}
+	return nil
+}
+
+func newEnvelopeStorage(t *testing.T, size int, opts ...Option) (*SecureStorage, *fakeKeyWrapper) {
+	t.Helper()
+
+	w := &fakeKeyWrapper{t: t}
+	opts = append(opts, func(c *storageConfig) { c.keyWrapper = w })
+	s := newTestStorage(t, size, opts...)
+	w.storage.Store(s)
+	return s, w
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	s, w := newEnvelopeStorage(t, 128)
+	data := []byte("database password")
+	mustStore(t, s, data)
+
+	if s.Used() != len(data) {
+		t.Fatalf("Used() = %d, want %d", s.Used(), len(data))
+	}
+	requireContent(t, s, data)
+
+	b, err := s.RetrieveBuffer(8)
+	if err != nil {
+		t.Fatalf("RetrieveBuffer failed: %v", err)
+	}
+	defer b.Close()
+	if !bytes.Equal(b.Bytes(), data[:8]) {
+		t.Fatalf("RetrieveBuffer(8) = %q, want %q", b.Bytes(), data[:8])
+	}
+
+	if err := s.Rotate([]byte("new")); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	dst := make([]byte, 8)
+	n, err := s.DrainTo(dst)
+	if err != nil {
+		t.Fatalf("DrainTo failed: %v", err)
+	}
+	if string(dst[:n]) != "new" {
+		t.Fatalf("DrainTo = %q, want %q", dst[:n], "new")
+	}
+	if got := w.calls.Load(); got != 5 {
+		t.Fatalf("key wrapper called %d times, want 5", got)
+	}
+}
+
+func TestEnvelopeSkipsKMSWhenRefused(t *testing.T) {
+	s, w := newEnvelopeStorage(t, 128, WithMaxRetrievals(1))
+	mustStore(t, s, []byte("secret"))
+	if _, err := s.Retrieve(6); err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	calls := w.calls.Load()
+
+	if _, err := s.Retrieve(6); !errors.Is(err, ErrRetrievalLimitExceeded) {
+		t.Fatalf("Retrieve past the limit = %v, want ErrRetrievalLimitExceeded", err)
+	}
+	if got := w.calls.Load(); got != calls {
+		t.Fatalf("key wrapper called for a refused retrieval")
+	}
+}
+
+func TestEnvelopeRejectsRawAccess(t *testing.T) {
+	s, _ := newEnvelopeStorage(t, 128)
+	mustStore(t, s, []byte("secret"))
+
+	if _, err := s.ReadAt(make([]byte, 4), 0); !errors.Is(err, ErrEncodedContent) {
+		t.Fatalf("ReadAt = %v, want ErrEncodedContent", err)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrLocked is returned by the Try* methods when another operation
+	// currently holds the storage.
+	ErrLocked = errors.New("storage is locked by another operation")
+
+	// ErrSlotNotFound is returned when a named slot does not exist.
+	ErrSlotNotFound = errors.New("named slot not found")
+
+	// ErrRetrievalLimitExceeded is returned once a storage created with
+	// WithMaxRetrievals has been read the maximum number of times.
+	ErrRetrievalLimitExceeded = errors.New("retrieval limit exceeded")
+
+	// ErrNoSerializationKey is returned when serializing a storage created
+	// without WithSerializationKey while LSECO_SERIALIZATION_KEY is unset.
+	ErrNoSerializationKey = errors.New("no serialization key configured")
+
+	// ErrExpired is returned when retrieving content stored with
+	// StoreWithTTL after its deadline has passed.
+	ErrExpired = errors.New("stored secret has expired")
+
+	// ErrPoolExhausted is returned by SecureStoragePool.Get when every
+	// storage in the pool is in use.
+	ErrPoolExhausted = errors.New("secure storage pool exhausted")
+
+	// ErrPoolClosed is returned when getting a storage from a closed
+	// SecureStoragePool.
+	ErrPoolClosed = errors.New("secure storage pool closed")
+
+	// ErrDataTruncation is returned by Resize when the new size cannot
+	// hold the data currently stored.
+	ErrDataTruncation = errors.New("resize would truncate stored data")
+
+	// ErrIntegrityViolation is returned when retrieving content whose
+	// integrity tag no longer matches, i.e. the locked memory was
+	// modified outside of the SecureStorage API. Copies, seals, and writes
+	// that keep part of the existing content fail with it too, so the
+	// corruption is never retagged as valid; Wipe clears the storage.
+	ErrIntegrityViolation = errors.New("secure memory integrity check failed")
+
+	// ErrUnknownSnapshot is returned by Rollback for a token that does not
+	// refer to a retained snapshot.
+	ErrUnknownSnapshot = errors.New("unknown snapshot")
+
+	// ErrInvalidLength is returned by Shrink for a length outside
+	// [0, Used()].
+	ErrInvalidLength = errors.New("invalid length")
+
+	// ErrOutOfBounds is returned by Seek for a position outside
+	// [0, Size()].
+	ErrOutOfBounds = errors.New("position out of bounds")
+
+	// ErrInvalidAlignment is returned by NewSecureStorageAligned for an
+	// alignment that is not a positive power of two.
+	ErrInvalidAlignment = errors.New("alignment must be a positive power of two")
+
+	// ErrDestinationTooSmall is returned by CopyTo when the destination
+	// cannot hold all of the source's content.
+	ErrDestinationTooSmall = errors.New("destination storage too small")
+
+	// ErrInsufficientSpace is returned by AppendTo when the destination
+	// has too little free capacity for the source's content.
+	ErrInsufficientSpace = errors.New("insufficient space in destination storage")
+
+	// ErrKeyNotFound is returned by SecureKeyRing for a key ID that is not
+	// in the ring.
+	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrKeyExists is returned by SecureKeyRing.Add for a key ID that is
+	// already in the ring.
+	ErrKeyExists = errors.New("key already exists")
+
+	// ErrActiveKey is returned by SecureKeyRing.Remove for the active key.
+	ErrActiveKey = errors.New("cannot remove the active key")
+
+	// ErrRateLimitExceeded is returned when a storage created with
+	// WithRetrievalRateLimit is read faster than the limit allows.
+	ErrRateLimitExceeded = errors.New("retrieval rate limit exceeded")
+
+	// ErrSizeMismatch is returned by Diff and XOR when the two storages hold
+	// different amounts of data.
+	ErrSizeMismatch = errors.New("stored lengths differ")
+
+	// ErrNoGobEncryptionKey is returned by GobEncode and GobDecode before
+	// SetGobEncryptionKey has been called.
+	ErrNoGobEncryptionKey = errors.New("no gob encryption key set")
+
+	// ErrInvalidAllocator is returned when creating a storage with a
+	// WithAllocator allocator whose alloc or free callback is nil.
+	ErrInvalidAllocator = errors.New("allocator callbacks must not be nil")
+
+	// ErrVaultTransitDestroyed is returned by VaultTransitStorage after
+	// Destroy.
+	ErrVaultTransitDestroyed = errors.New("vault transit storage destroyed")
+
+	// ErrTokenExpired is returned by AccessToken.Retrieve once the token
+	// has expired. It wraps ErrExpired.
+	ErrTokenExpired = fmt.Errorf("access token expired: %w", ErrExpired)
+
+	// ErrEnvVarNotSet is returned by LoadFromEnv when the variable is not
+	// set.
+	ErrEnvVarNotSet = errors.New("environment variable not set")
+
+	// ErrInvalidEncoding is returned by NewSecureStorageFromBase64 when
+	// the input is not valid base64.
+	ErrInvalidEncoding = errors.New("invalid base64 encoding")
+
+	// ErrDecodedSizeMismatch is returned by NewSecureStorageFromBase64
+	// and NewSecureStorageFromHex when the decoded secret is larger than
+	// the requested size.
+	ErrDecodedSizeMismatch = errors.New("decoded secret exceeds storage size")
+
+	// ErrOddLengthHex is returned by NewSecureStorageFromHex when the
+	// input has an odd number of digits.
+	ErrOddLengthHex = errors.New("odd length hex string")
+
+	// ErrInvalidHexChar is returned by NewSecureStorageFromHex when the
+	// input contains a character that is not a hex digit.
+	ErrInvalidHexChar = errors.New("invalid hex character")
+
+	// ErrSGXUnavailable is returned when creating a storage with
+	// WithSGXEnclave while SGXAvailable reports false.
+	ErrSGXUnavailable = errors.New("SGX enclave backend not available")
+
+	// ErrDataTooLarge is returned by Rotate when the new secret does not
+	// fit in the storage.
+	ErrDataTooLarge = errors.New("data exceeds storage capacity")
+
+	// ErrSizeExceedsMax is returned when a storage would be created,
+	// resized, or unmarshaled with a size above the WithMaxSize or
+	// SetGlobalMaxSize cap.
+	ErrSizeExceedsMax = errors.New("size exceeds maximum storage size")
+
+	// ErrInvalidPKCS8 is returned by NewSecureStorageFromPKCS8 for input
+	// that is not a PKCS #8 PrivateKeyInfo.
+	ErrInvalidPKCS8 = errors.New("invalid PKCS #8 private key")
+
+	// ErrInvalidNonce is returned by XChaCha20Encrypt for a nonce that is
+	// not 24 bytes.
+	ErrInvalidNonce = errors.New("invalid nonce length")
+
+	// ErrEncodedContent is returned by methods that work on the raw
+	// bytes, such as ReadAt, Write, and Compare, when a storage keeps its
+	// content compressed or envelope encrypted, and when serialized
+	// content is decoded into a storage with a different encoding.
+	ErrEncodedContent = errors.New("operation not supported on encoded content")
+
+	// ErrNoBackupPassphrase is returned by Backup and Restore when no
+	// WithBackupPassphrase option was given.
+	ErrNoBackupPassphrase = errors.New("no backup passphrase configured")
+
+	// ErrBackupCorrupted is returned by Restore when the backup checksum
+	// does not match.
+	ErrBackupCorrupted = errors.New("backup checksum mismatch")
+)
+
+// ErrCode is a failed result code from the C layer, mirroring the
+// LSECO_ERR_* values. Errors from C calls wrap an ErrCode, so callers can
+// test for a specific failure with errors.Is(err, ErrNoMemory) or recover
+// the code with errors.As.
+type ErrCode int
+
+// Result codes returned by the C layer.
+const (
+	ErrNullPointer   ErrCode = C.LSECO_ERR_NULL_PTR
+	ErrNoMemory      ErrCode = C.LSECO_ERR_ALLOC_FAILED
+	ErrLockFailed    ErrCode = C.LSECO_ERR_LOCK_FAILED
+	ErrProtectFailed ErrCode = C.LSECO_ERR_PROTECT_FAILED
+	ErrInvalidSize   ErrCode = C.LSECO_ERR_INVALID_SIZE
+	ErrIO            ErrCode = C.LSECO_ERR_IO
+
+	// ErrUnknown is returned by ParseErrorString for a message it does
+	// not recognize. No C result code maps to it.
+	ErrUnknown ErrCode = -1 << 31
+)
+
+// errCodes lists every ErrCode with a message of its own.
+var errCodes = [...]ErrCode{ErrNullPointer, ErrNoMemory, ErrLockFailed, ErrProtectFailed, ErrInvalidSize, ErrIO}
+
+// Error returns the message from lseco_error_string.
+func (c ErrCode) Error() string {
+	return C.GoString(C.lseco_error_string(C.int(c)))
+}
+
+// resultError converts a failed C result code into an error for op.
+func resultError(op string, result C.int) error {
+	return fmt.Errorf("%s failed: %w", op, ErrCode(result))
+}
+
+// ParseErrorString maps a message from lseco_error_string back to its
+// ErrCode, for classifying errors captured as text, such as a
+// subprocess's stderr. A trailing message also matches, so the output of
+// Error on a wrapped error, like "store failed: Invalid size parameter",
+// parses too. Unrecognized messages, perhaps from a newer C library,
+// yield ErrUnknown rather than an error; the error is only non-nil for
+// the success message, which names no failure.
+func ParseErrorString(s string) (ErrCode, error) {
+	s = strings.TrimSpace(s)
+	if s == C.GoString(C.lseco_error_string(C.LSECO_SUCCESS)) {
+		return 0, fmt.Errorf("%q is not an error message", s)
+	}
+	for _, code := range errCodes {
+		msg := code.Error()
+		if s == msg || strings.HasSuffix(s, ": "+msg) {
+			return code, nil
+		}
+	}
+	return ErrUnknown, nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+	"time"
+	"weak"
+)
+
+// StoreWithTTL stores data like Store and schedules the storage to be
+// wiped once ttl has elapsed. Retrievals after the deadline return
+// ErrExpired, even if the background wipe has not run yet. A later Store
+// or Wipe cancels the expiry.
+func (s *SecureStorage) StoreWithTTL(data []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("ttl must be positive, got %v", ttl)
+	}
+
+	key, err := s.cfg.generateEnvelopeKey()
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.storeLocked(data, key); err != nil {
+		return err
+	}
+
+	s.expiresAt = time.Now().Add(ttl)
+	expiry.schedule(s, s.expiresAt, s.expiryGen)
+	s.record(opStore)
+	return nil
+}
+
+// WithOnExpiry calls fn in a new goroutine when a StoreWithTTL deadline
+// passes, just before the storage is wiped, e.g. to fetch a fresh secret
+// or log the event. While fn runs, Retrieve returns ErrExpired, but fn
+// may call Store or StoreWithTTL to renew the secret, in which case the
+// wipe is skipped. Otherwise the storage is wiped once fn returns.
+func WithOnExpiry(fn func(*SecureStorage)) Option {
+	return func(c *storageConfig) {
+		c.onExpiry = fn
+	}
+}
+
+// expiredLocked reports whether the content has passed its TTL deadline.
+func (s *SecureStorage) expiredLocked() bool {
+	return !s.expiresAt.IsZero() && !time.Now().Before(s.expiresAt)
+}
+
+// clearExpiryLocked cancels a pending expiry and removes it from the
+// scheduler.
+func (s *SecureStorage) clearExpiryLocked() {
+	s.expiresAt = time.Time{}
+	s.expiryGen++
+	if s.expiry != nil {
+		expiry.cancel(s.expiry)
+	}
+}
+
+// expire wipes the storage if gen still identifies its current expiry,
+// after running the WithOnExpiry callback if there is one.
+func (s *SecureStorage) expire(gen uint64) {
+	s.mu.lockInternal()
+	defer s.mu.unlockInternal()
+
+	if s.handle == nil || s.expiryGen != gen {
+		return
+	}
+	if fn := s.cfg.onExpiry; fn != nil {
+		// The scheduler must not wait for fn, and fn may need s.mu.
+		go func() {
+			fn(s)
+			s.expireAfterCallback(gen)
+		}()
+		return
+	}
+	s.expireLocked()
+}
+
+// expireAfterCallback wipes the storage unless the WithOnExpiry callback
+// renewed or destroyed it.
+func (s *SecureStorage) expireAfterCallback(gen uint64) {
+	s.mu.lockInternal()
+	defer s.mu.unlockInternal()
+
+	if s.handle == nil || s.expiryGen != gen {
+		return
+	}
+	s.expireLocked()
+}
+
+// expireLocked wipes the expired content.
+func (s *SecureStorage) expireLocked() {
+	if err := s.wipeAllLocked(); err != nil {
+		warnLeak(s.cfg.logger, "lseco: failed to wipe expired SecureStorage", s.size)
+	}
+}
+
+// expiry is the package-wide scheduler behind StoreWithTTL.
+var expiry expiryScheduler
+
+// expiryEntry is the scheduler's record for one storage, reused across
+// reschedules. It holds the storage weakly, so a storage dropped without
+// Destroy can still be collected and finalized before its deadline.
+type expiryEntry struct {
+	storage  weak.Pointer[SecureStorage]
+	deadline time.Time
+	gen      uint64
+	index    int // position in expiryHeap, or -1 when not scheduled
+}
+
+// expiryHeap is a min-heap of entries ordered by deadline.
+type expiryHeap []*expiryEntry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *expiryHeap) Push(x any) {
+	e := x.(*expiryEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *expiryHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	e.index = -1
+	return e
+}
+
+// expiryScheduler wipes storages when their deadlines pass. A single
+// goroutine, started on first use, sleeps until the earliest deadline.
+type expiryScheduler struct {
+	once    sync.Once
+	mu      sync.Mutex
+	entries expiryHeap
+	wake    chan struct{}
+}
+
+// schedule sets the expiry of s, replacing any pending one. The caller
+// holds s.mu.
+func (e *expiryScheduler) schedule(s *SecureStorage, deadline time.Time, gen uint64) {
+	e.once.Do(func() {
+		e.wake = make(chan struct{}, 1)
+		go e.run()
+	})
+
+	e.mu.Lock()
+	entry := s.expiry
+	if entry == nil {
+		entry = &expiryEntry{storage: weak.Make(s), index: -1}
+		s.expiry = entry
+	}
+	entry.deadline = deadline
+	entry.gen = gen
+	if entry.index >= 0 {
+		heap.Fix(&e.entries, entry.index)
+	} else {
+		heap.Push(&e.entries, entry)
+	}
+	earliest := e.entries[0] == entry
+	e.mu.Unlock()
+
+	if earliest {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// cancel removes entry from the heap if it is scheduled. A run that has
+// already popped it is stopped by the expiry generation instead.
+func (e *expiryScheduler) cancel(entry *expiryEntry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if entry.index >= 0 {
+		heap.Remove(&e.entries, entry.index)
+	}
+}
+
+// len returns the number of scheduled expiries.
+func (e *expiryScheduler) len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.entries)
+}
+
+func (e *expiryScheduler) run() {
+	type dueExpiry struct {
+		storage *SecureStorage
+		gen     uint64
+	}
+
+	for {
+		e.mu.Lock()
+		now := time.Now()
+		var due []dueExpiry
+		for len(e.entries) > 0 && !e.entries[0].deadline.After(now) {
+			entry := heap.Pop(&e.entries).(*expiryEntry)
+			// A collected storage was finalized, so there is nothing to wipe.
+			if s := entry.storage.Value(); s != nil {
+				due = append(due, dueExpiry{storage: s, gen: entry.gen})
+			}
+		}
+		wait := time.Duration(-1)
+		if len(e.entries) > 0 {
+			wait = e.entries[0].deadline.Sub(now)
+		}
+		e.mu.Unlock()
+
+		// Expire outside e.mu so a storage holding its own lock while
+		// scheduling cannot deadlock against the scheduler.
+		for _, d := range due {
+			d.storage.expire(d.gen)
+		}
+
+		if wait < 0 {
+			<-e.wake
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-e.wake:
+		}
+		timer.Stop()
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStoreWithTTLExpires(t *testing.T) {
+	s := newTestStorage(t, 32)
+	if err := s.StoreWithTTL([]byte("secret"), 10*time.Millisecond); err != nil {
+		t.Fatalf("StoreWithTTL failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := s.Retrieve(6); !errors.Is(err, ErrExpired) {
+		t.Fatalf("Retrieve after the deadline = %v, want ErrExpired", err)
+	}
+}
+
+func TestStoreWithTTLReschedules(t *testing.T) {
+	before := expiry.len()
+	s := newTestStorage(t, 32)
+
+	for range 10 {
+		if err := s.StoreWithTTL([]byte("secret"), time.Hour); err != nil {
+			t.Fatalf("StoreWithTTL failed: %v", err)
+		}
+	}
+	if got := expiry.len(); got != before+1 {
+		t.Fatalf("scheduled expiries after 10 StoreWithTTL = %d, want %d", got, before+1)
+	}
+
+	if err := s.Wipe(); err != nil {
+		t.Fatalf("Wipe failed: %v", err)
+	}
+	if got := expiry.len(); got != before {
+		t.Fatalf("scheduled expiries after Wipe = %d, want %d", got, before)
+	}
+}
+
+func TestStoreWithTTLDoesNotPinStorage(t *testing.T) {
+	before := expiry.len()
+	func() {
+		s, err := NewSecureStorage(32, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+		if err != nil {
+			t.Fatalf("NewSecureStorage failed: %v", err)
+		}
+		if err := s.StoreWithTTL([]byte("secret"), time.Hour); err != nil {
+			t.Fatalf("StoreWithTTL failed: %v", err)
+		}
+	}()
+
+	// The finalizer destroys the dropped storage, which cancels its expiry.
+	deadline := time.Now().Add(5 * time.Second)
+	for expiry.len() != before {
+		if time.Now().After(deadline) {
+			t.Fatalf("dropped storage was not collected before its deadline")
+		}
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
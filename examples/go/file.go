@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SaveToFile seals the stored content with AES-256-GCM under encKey, a
+// 32-byte caller-supplied key, and writes it to path with mode 0600. The
+// ciphertext goes to a new temporary file in the same directory, which is
+// synced and then renamed over path, so readers see either the old file
+// or the complete new one. Files are opened with O_NOFOLLOW where the
+// platform supports it, so a planted symlink is not written through.
+//
+// Saving counts as one retrieval for WithMaxRetrievals.
+func (s *SecureStorage) SaveToFile(path string, encKey []byte) error {
+	aead, err := newAEAD(encKey)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return err
+	}
+	sealed, err := s.sealLocked(aead, nil)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, sealed); err != nil {
+		return err
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return nil
+}
+
+// LoadFromFile decrypts a file written by SaveToFile under encKey into a
+// new storage of size bytes, created with opts. It returns
+// ErrDataTruncation if size cannot hold the saved content. The caller
+// must destroy the storage.
+func LoadFromFile(path string, size int, encKey []byte, opts ...Option) (*SecureStorage, error) {
+	aead, err := newAEAD(encKey)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDONLY|openNoFollow, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sealed, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := newStorageConfig(opts)
+	s := &SecureStorage{
+		mu:      storageMutex{detect: cfg.detectConcurrency},
+		cfg:     cfg,
+		limiter: newRetrievalLimiter(cfg.retrievalRate),
+	}
+	if err := s.openLocked(aead, sealed, nil); err != nil {
+		return nil, err
+	}
+	if s.size != size {
+		if err := s.Resize(size); err != nil {
+			s.Destroy()
+			return nil, err
+		}
+	}
+	s.record(opStore)
+	return s, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and
+// renames it into place.
+func writeFileAtomic(path string, data []byte) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	var f *os.File
+	var tmp string
+	for i := 0; ; i++ {
+		tmp = filepath.Join(dir, fmt.Sprintf(".%s.tmp%d-%d", name, os.Getpid(), i))
+		var err error
+		f, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL|openNoFollow, 0o600)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) || i == 100 {
+			return err
+		}
+	}
+
+	_, err := f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
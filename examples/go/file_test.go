@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoadFile(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, serializationKeySize)
+	data := []byte("tls private key")
+	path := filepath.Join(t.TempDir(), "secret.bin")
+	s := newTestStorage(t, 32)
+	mustStore(t, s, data)
+
+	if err := s.SaveToFile(path, key); err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("file mode = %v, want 0600", perm)
+	}
+
+	loaded, err := LoadFromFile(path, 32, key)
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	t.Cleanup(loaded.Destroy)
+	requireContent(t, loaded, data)
+
+	if _, err := LoadFromFile(path, 32, bytes.Repeat([]byte{6}, serializationKeySize)); err == nil {
+		t.Fatal("LoadFromFile under the wrong key succeeded")
+	}
+}
+
+func TestSaveToFileReplacesSymlink(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, serializationKeySize)
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	link := filepath.Join(dir, "link")
+	if err := os.WriteFile(target, []byte("decoy"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	s := newTestStorage(t, 32)
+	mustStore(t, s, []byte("secret"))
+
+	if err := s.SaveToFile(link, key); err != nil {
+		t.Fatalf("SaveToFile failed: %v", err)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(got) != "decoy" {
+		t.Fatal("SaveToFile wrote through the symlink")
+	}
+	if info, err := os.Lstat(link); err != nil || !info.Mode().IsRegular() {
+		t.Fatalf("link was not replaced by a regular file: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+var leakLogger atomic.Pointer[slog.Logger]
+
+// SetLeakLogger sets the logger used to warn when a SecureStorage or
+// SecureBuffer is garbage collected without being destroyed or closed.
+// Passing nil restores slog.Default().
+func SetLeakLogger(l *slog.Logger) {
+	leakLogger.Store(l)
+}
+
+// finalizeStorage is the best-effort cleanup registered on every
+// SecureStorage. The GC gives no guarantee about when (or whether) it
+// runs, so it warns loudly: callers must still call Destroy.
+func finalizeStorage(s *SecureStorage) {
+	if s.handle == nil {
+		return
+	}
+
+	warnLeak(s.cfg.logger, "lseco: SecureStorage was garbage collected without Destroy", s.size)
+	s.Destroy()
+}
+
+// warnLeak logs msg to logger, falling back to the SetLeakLogger logger
+// and then to slog.Default().
+func warnLeak(logger *slog.Logger, msg string, size int) {
+	if logger == nil {
+		logger = leakLogger.Load()
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Warn(msg, "size", size)
+}
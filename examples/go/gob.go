@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"sync/atomic"
+)
+
+// gobKey holds the key set by SetGobEncryptionKey in locked memory. It is
+// never freed.
+var gobKey atomic.Pointer[SecureBuffer]
+
+// SetGobEncryptionKey sets the process-wide 32-byte AES-256 key used by
+// GobEncode and GobDecode. The key is copied into locked memory, so the
+// caller may zero key afterwards. It can be set only once: a second call,
+// or a key of the wrong size, panics.
+func SetGobEncryptionKey(key []byte) {
+	if len(key) != serializationKeySize {
+		panic(fmt.Sprintf("lseco: gob encryption key must be %d bytes, got %d", serializationKeySize, len(key)))
+	}
+
+	buf, err := NewSecureBuffer(len(key))
+	if err != nil {
+		panic("lseco: " + err.Error())
+	}
+	copy(buf.Bytes(), key)
+	if !gobKey.CompareAndSwap(nil, buf) {
+		buf.Close()
+		panic("lseco: SetGobEncryptionKey called more than once")
+	}
+}
+
+// GobEncode implements gob.GobEncoder, so a *SecureStorage can be sent
+// through gob streams and net/rpc. The content is sealed with AES-256-GCM
+// under the SetGobEncryptionKey key, in the MarshalBinary layout, and
+// plaintext never leaves the C buffer. It returns ErrNoGobEncryptionKey
+// if no key was set.
+//
+// Encoding counts as one retrieval for WithMaxRetrievals.
+func (s *SecureStorage) GobEncode() ([]byte, error) {
+	aead, err := newGobAEAD()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out, err := s.exportSealedLocked(aead)
+	if err != nil {
+		return nil, err
+	}
+	s.record(opRetrieve)
+	return out, nil
+}
+
+// GobDecode implements gob.GobDecoder. It decrypts output of GobEncode
+// into a new C buffer like UnmarshalBinary.
+func (s *SecureStorage) GobDecode(data []byte) error {
+	aead, err := newGobAEAD()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.openLocked(aead, data, nil); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
+
+// newGobAEAD returns the cipher for GobEncode and GobDecode.
+func newGobAEAD() (cipher.AEAD, error) {
+	key := gobKey.Load()
+	if key == nil {
+		return nil, ErrNoGobEncryptionKey
+	}
+	return newAEAD(key.Bytes())
+}
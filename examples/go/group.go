@@ -0,0 +1,145 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// SecureStorageGroup ties related storages together, such as the keys of
+// one hierarchy, so that tampering with one is treated as compromising
+// all of them: as soon as any operation on a member fails with
+// ErrIntegrityViolation, every member is wiped before that call returns.
+// A storage belongs to at most one group. It is safe for concurrent use
+// by multiple goroutines.
+type SecureStorageGroup struct {
+	mu      sync.Mutex
+	members []*SecureStorage
+}
+
+// groupMembership serializes Add and Remove, so that checking a storage's
+// current group and moving it happen as one step.
+var groupMembership sync.Mutex
+
+// storageMutex is the lock of a SecureStorage. An integrity violation,
+// wherever verifyLocked detects it, leaves the storage's group in
+// pendingWipe, and Unlock wipes the group once the lock is released,
+// since the wipe locks every member, s included.
+type storageMutex struct {
+	sync.RWMutex
+	pendingWipe *SecureStorageGroup
+
+	detect bool        // see WithConcurrencyDetector
+	inUse  atomic.Bool // set while a method holds or waits for the lock
+}
+
+// Unlock clears the in-use mark and releases the write lock, then runs
+// any pending group wipe.
+func (m *storageMutex) Unlock() {
+	g := m.takePendingWipe()
+	m.leave()
+	m.RWMutex.Unlock()
+	g.wipeAll()
+}
+
+// takePendingWipe clears and returns the pending group wipe. It must be
+// called with the write lock held.
+func (m *storageMutex) takePendingWipe() *SecureStorageGroup {
+	g := m.pendingWipe
+	m.pendingWipe = nil
+	return g
+}
+
+// NewGroup returns a group holding storages.
+func NewGroup(storages ...*SecureStorage) *SecureStorageGroup {
+	g := &SecureStorageGroup{}
+	for _, s := range storages {
+		g.Add(s)
+	}
+	return g
+}
+
+// Add makes s a member, moving it out of any other group. Adding a
+// member again has no effect.
+func (g *SecureStorageGroup) Add(s *SecureStorage) {
+	groupMembership.Lock()
+	defer groupMembership.Unlock()
+
+	s.mu.lockInternal()
+	prev := s.group
+	s.mu.unlockInternal()
+	if prev == g {
+		return
+	}
+	if prev != nil {
+		prev.removeLocked(s)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s.mu.lockInternal()
+	s.group = g
+	s.mu.unlockInternal()
+	g.members = append(g.members, s)
+}
+
+// Remove takes s out of the group. It is a no-op if s is not a member.
+func (g *SecureStorageGroup) Remove(s *SecureStorage) {
+	groupMembership.Lock()
+	defer groupMembership.Unlock()
+
+	g.removeLocked(s)
+}
+
+// removeLocked is Remove with groupMembership held.
+func (g *SecureStorageGroup) removeLocked(s *SecureStorage) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	i := slices.Index(g.members, s)
+	if i < 0 {
+		return
+	}
+	g.members = slices.Delete(g.members, i, i+1)
+
+	s.mu.lockInternal()
+	if s.group == g {
+		s.group = nil
+	}
+	s.mu.unlockInternal()
+}
+
+// Members returns the current members.
+func (g *SecureStorageGroup) Members() []*SecureStorage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return slices.Clone(g.members)
+}
+
+// wipeAll wipes every member, logging any that cannot be wiped. It is a
+// no-op on a nil group.
+func (g *SecureStorageGroup) wipeAll() {
+	if g == nil {
+		return
+	}
+	for _, s := range g.Members() {
+		s.wipeForGroup()
+	}
+}
+
+// wipeForGroup is Wipe for wipeAll, skipping destroyed storages.
+func (s *SecureStorage) wipeForGroup() {
+	s.mu.lockInternal()
+	defer s.mu.unlockInternal()
+
+	if s.handle == nil {
+		return
+	}
+	if err := s.wipeAllLocked(); err != nil {
+		warnLeak(s.cfg.logger, "lseco: failed to wipe SecureStorage after group integrity violation", s.size)
+		return
+	}
+	s.clearExpiryLocked()
+}
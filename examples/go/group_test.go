@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGroupWipedOnIntegrityViolation(t *testing.T) {
+	ops := map[string]func(a, b *SecureStorage) error{
+		"Retrieve": func(a, b *SecureStorage) error {
+			_, err := a.Retrieve(1)
+			return err
+		},
+		"TryRetrieve": func(a, b *SecureStorage) error {
+			_, err := a.TryRetrieve(1)
+			return err
+		},
+		"Validate": func(a, b *SecureStorage) error {
+			return a.Validate(func([]byte) error { return nil })
+		},
+		"Rotate": func(a, b *SecureStorage) error {
+			return a.Rotate([]byte("next"))
+		},
+		"CopyTo": func(a, b *SecureStorage) error {
+			return a.CopyTo(b)
+		},
+	}
+	for name, op := range ops {
+		t.Run(name, func(t *testing.T) {
+			a := newTestStorage(t, 32)
+			b := newTestStorage(t, 32)
+			mustStore(t, a, []byte("alpha"))
+			mustStore(t, b, []byte("bravo"))
+			NewGroup(a, b)
+			corrupt(t, a)
+
+			if err := op(a, b); !errors.Is(err, ErrIntegrityViolation) {
+				t.Fatalf("%s after corruption = %v, want ErrIntegrityViolation", name, err)
+			}
+			if b.Used() != 0 {
+				t.Fatalf("group member not wiped: Used() = %d", b.Used())
+			}
+		})
+	}
+}
+
+func TestGroupAddMovesMember(t *testing.T) {
+	s := newTestStorage(t, 8)
+	g1, g2 := NewGroup(s), NewGroup()
+
+	g2.Add(s)
+	if len(g1.Members()) != 0 || len(g2.Members()) != 1 {
+		t.Fatalf("members after move: %d and %d, want 0 and 1", len(g1.Members()), len(g2.Members()))
+	}
+}
@@ -0,0 +1,27 @@
+//go:build lseco_unsafe
+
+package main
+
+import "unsafe"
+
+// Handle returns the underlying lseco_handle_t as a uintptr, so custom
+// cgo extensions, such as HSM bindings, can pass it to C functions that
+// take a handle. It returns 0 once the storage is destroyed.
+//
+// The handle is only valid while the storage is alive and not destroyed,
+// resized, or replaced by UnmarshalBinary, any of which frees or swaps
+// the C region; keep the storage reachable with runtime.KeepAlive until
+// the C call returns. The storage's lock is not held while you use it,
+// so the caller must make sure no other goroutine touches the storage
+// in the meantime, and anything written through the handle is not
+// covered by the integrity tag: the next retrieval will fail with
+// ErrIntegrityViolation. Because it bypasses every guarantee the type
+// makes, Handle is only available when built with -tags lseco_unsafe.
+//
+//go:nosplit
+func (s *SecureStorage) Handle() uintptr {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return uintptr(unsafe.Pointer(s.handle))
+}
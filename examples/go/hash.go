@@ -0,0 +1,90 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"crypto/sha256"
+	"hash"
+	"unsafe"
+)
+
+// Hash writes the stored content to h and returns h.Sum(nil), for example
+// to compute a SHA-256 key fingerprint without retrieving the key. The
+// digest is an ordinary slice; the content itself is never copied to the
+// Go heap.
+//
+// As in WriteTo, the content goes through a locked staging buffer rather
+// than a stack array, which would escape to the heap once passed to
+// h.Write, and counts as one retrieval.
+func (s *SecureStorage) Hash(h hash.Hash) ([]byte, error) {
+	if err := checkRaw(s); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	staging, err := NewSecureBuffer(stagingChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	defer staging.Close()
+	chunk := staging.Bytes()
+
+	for off := 0; off < s.length; {
+		n := min(s.length-off, len(chunk))
+
+		result := C.lseco_retrieve_at(
+			s.handle,
+			C.size_t(off),
+			unsafe.Pointer(&chunk[0]),
+			C.size_t(n),
+		)
+		if result != C.LSECO_SUCCESS {
+			return nil, resultError("hash", result)
+		}
+
+		h.Write(chunk[:n])
+		off += n
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return h.Sum(nil), nil
+}
+
+// Checksum returns the SHA-256 of the stored content, so two copies of a
+// key can be compared without revealing it. Unlike Hash, it needs no
+// hash.Hash: sha256.Sum256 runs over the mapped C buffer with its state
+// on the stack, so nothing is allocated. It counts as one retrieval.
+func (s *SecureStorage) Checksum() ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	if err := checkRaw(s); err != nil {
+		return sum, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return sum, err
+	}
+
+	err := s.withViewLocked(func(view []byte) error {
+		sum = sha256.Sum256(view[:s.length])
+		return nil
+	})
+	if err != nil {
+		return sum, err
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return sum, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NewSecureStorageFromHex decodes hexStr, such as the output of
+// "openssl rand -hex 32", into a new storage of size bytes created with
+// opts. Upper- and lowercase digits are accepted, and surrounding
+// whitespace is ignored. As in NewSecureStorageFromBase64, the secret is
+// decoded into a locked SecureBuffer that is zeroed before returning.
+//
+// It returns ErrOddLengthHex or ErrInvalidHexChar if hexStr is malformed,
+// and ErrDecodedSizeMismatch if the decoded secret is longer than size.
+func NewSecureStorageFromHex(hexStr string, size int, opts ...Option) (*SecureStorage, error) {
+	hexStr = strings.TrimSpace(hexStr)
+	if hexStr == "" {
+		return nil, fmt.Errorf("hex string is empty")
+	}
+	if len(hexStr)%2 != 0 {
+		return nil, fmt.Errorf("%w: %d digits", ErrOddLengthHex, len(hexStr))
+	}
+	n := hex.DecodedLen(len(hexStr))
+	if n > size {
+		return nil, fmt.Errorf("%w: %d > %d", ErrDecodedSizeMismatch, n, size)
+	}
+
+	staging, err := NewSecureBuffer(n)
+	if err != nil {
+		return nil, err
+	}
+	defer staging.Close()
+
+	if _, err := hex.Decode(staging.Bytes(), []byte(hexStr)); err != nil {
+		var invalid hex.InvalidByteError
+		if errors.As(err, &invalid) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidHexChar, byte(invalid))
+		}
+		return nil, err
+	}
+
+	return NewSecureStorageFromBytes(staging.Bytes()[:n], size, opts...)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+)
+
+// Every C allocation is integrityTagSize bytes larger than the storage
+// size. That reserved tail holds an HMAC-SHA-256 tag over the whole user
+// region, recomputed after every write and checked before every
+// retrieval, so silent corruption of the locked pages is detected.
+const integrityTagSize = sha256.Size
+
+// integrityKeyInfo is the HKDF context for integrity keys.
+const integrityKeyInfo = "lseco integrity tag v1"
+
+// newIntegrityKey derives a per-storage HMAC key from a random seed.
+func newIntegrityKey() ([]byte, error) {
+	seed := make([]byte, 32)
+	defer SecureZero(seed)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("failed to generate integrity seed: %w", err)
+	}
+	return hkdf.Key(sha256.New, seed, nil, integrityKeyInfo, sha256.Size)
+}
+
+// tagMACLocked returns the storage's HMAC, reset for a new tag. It is
+// created on first use and then reused, so tagging does not allocate.
+func (s *SecureStorage) tagMACLocked() hash.Hash {
+	if s.mac == nil {
+		s.mac = hmac.New(sha256.New, s.integrityKey)
+	} else {
+		s.mac.Reset()
+	}
+	return s.mac
+}
+
+// retagLocked recomputes the integrity tag after the content changed.
+func (s *SecureStorage) retagLocked() error {
+	return s.mapLocked(func(region []byte) error {
+		mac := s.tagMACLocked()
+		mac.Write(region[:s.size])
+		mac.Sum(region[s.size:s.size])
+		return nil
+	})
+}
+
+// verifyLocked returns ErrIntegrityViolation if the content no longer
+// matches its tag. A mismatch also schedules the wipe of s's group, if
+// any, for when s.mu is released.
+func (s *SecureStorage) verifyLocked() error {
+	ok := false
+	err := s.mapLocked(func(region []byte) error {
+		mac := s.tagMACLocked()
+		mac.Write(region[:s.size])
+		ok = hmac.Equal(mac.Sum(s.tagScratch[:0]), region[s.size:])
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.mu.pendingWipe = s.group
+		return ErrIntegrityViolation
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// corrupt flips a bit of the content behind the API's back, so the
+// integrity tag no longer matches.
+func corrupt(t *testing.T, s *SecureStorage) {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.withViewLocked(func(view []byte) error {
+		view[0] ^= 1
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to corrupt storage: %v", err)
+	}
+}
+
+func TestIntegrityViolation(t *testing.T) {
+	key := make([]byte, serializationKeySize)
+	tests := []struct {
+		name string
+		op   func(s *SecureStorage) error
+	}{
+		{"Retrieve", func(s *SecureStorage) error {
+			_, err := s.Retrieve(1)
+			return err
+		}},
+		{"RetrieveBuffer", func(s *SecureStorage) error {
+			b, err := s.RetrieveBuffer(1)
+			if err == nil {
+				b.Close()
+			}
+			return err
+		}},
+		{"Clone", func(s *SecureStorage) error {
+			c, err := s.Clone()
+			if err == nil {
+				c.Destroy()
+			}
+			return err
+		}},
+		{"Snapshot", func(s *SecureStorage) error {
+			_, err := s.Snapshot()
+			return err
+		}},
+		{"Seal", func(s *SecureStorage) error {
+			_, err := s.Seal(key)
+			return err
+		}},
+		{"Store", func(s *SecureStorage) error {
+			return s.Store([]byte("x"))
+		}},
+		{"WriteAt", func(s *SecureStorage) error {
+			_, err := s.WriteAt([]byte("x"), 10)
+			return err
+		}},
+		{"StoreNamed", func(s *SecureStorage) error {
+			return s.StoreNamed("slot", []byte("x"))
+		}},
+		{"Resize", func(s *SecureStorage) error {
+			return s.Resize(64)
+		}},
+		{"Compare", func(s *SecureStorage) error {
+			other, err := NewSecureStorageFromBytes([]byte("secret"), 16)
+			if err != nil {
+				return err
+			}
+			defer other.Destroy()
+			_, err = s.Compare(other)
+			return err
+		}},
+		{"SecureCompare", func(s *SecureStorage) error {
+			_, err := SecureCompare(s, []byte("secret"))
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t, 32)
+			mustStore(t, s, []byte("secret"))
+			corrupt(t, s)
+
+			if err := tt.op(s); !errors.Is(err, ErrIntegrityViolation) {
+				t.Fatalf("%s after corruption = %v, want ErrIntegrityViolation", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestIntegrityWipeRecovers(t *testing.T) {
+	s := newTestStorage(t, 32)
+	mustStore(t, s, []byte("secret"))
+	corrupt(t, s)
+
+	if err := s.Wipe(); err != nil {
+		t.Fatalf("Wipe failed: %v", err)
+	}
+	mustStore(t, s, []byte("fresh"))
+	requireContent(t, s, []byte("fresh"))
+}
+
+func TestIntegrityCopyDestination(t *testing.T) {
+	src := newTestStorage(t, 32)
+	mustStore(t, src, []byte("source"))
+	dst := newTestStorage(t, 32)
+	mustStore(t, dst, []byte("destination"))
+	corrupt(t, dst)
+
+	if err := src.CopyTo(dst); !errors.Is(err, ErrIntegrityViolation) {
+		t.Fatalf("CopyTo corrupted destination = %v, want ErrIntegrityViolation", err)
+	}
+}
+
+func TestIntegrityCompareOther(t *testing.T) {
+	s := newTestStorage(t, 32)
+	mustStore(t, s, []byte("secret"))
+	other := newTestStorage(t, 32)
+	mustStore(t, other, []byte("secret"))
+	corrupt(t, other)
+
+	if _, err := s.Compare(other); !errors.Is(err, ErrIntegrityViolation) {
+		t.Fatalf("Compare with a corrupted storage = %v, want ErrIntegrityViolation", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonEnvelope is the JSON form of a SecureStorage. Ciphertext holds the
+// MarshalBinary encoding; encoding/json base64-encodes it.
+type jsonEnvelope struct {
+	Ciphertext []byte `json:"ciphertext"`
+	Alg        string `json:"alg"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding the storage as
+// {"ciphertext":"<base64>","alg":"AES-256-GCM"}. The ciphertext is the
+// MarshalBinary encoding, so the same key rules apply and the output
+// never contains plaintext.
+func (s *SecureStorage) MarshalJSON() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sealed, err := s.marshalLocked()
+	if err != nil {
+		return nil, err
+	}
+	s.record(opRetrieve)
+	return json.Marshal(jsonEnvelope{Ciphertext: sealed, Alg: envelopeAlgorithm})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It decrypts output of
+// MarshalJSON into a new C buffer like UnmarshalBinary. A JSON null is
+// a no-op.
+func (s *SecureStorage) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var env jsonEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return err
+	}
+	if env.Alg != envelopeAlgorithm {
+		return fmt.Errorf("unsupported algorithm %q", env.Alg)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.unmarshalLocked(env.Ciphertext); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+)
+
+// maxDerivedKeySize is the longest output HKDF-SHA-256 can produce.
+const maxDerivedKeySize = 255 * sha256.Size
+
+// KeyDerivation derives a sub-key of outputSize bytes from the stored
+// content with HKDF-SHA-256 (RFC 5869), using label as the info string
+// and no salt, and returns it in a new storage that the caller must
+// destroy.
+//
+// HKDF runs in Go over the mapped C memory, like Seal: the content is
+// read from its locked region, the pseudorandom key is kept in a locked
+// scratch buffer, and output blocks are written straight into the new
+// storage. The HMAC state is not covered: crypto/hmac keeps its padded
+// key and the SHA-256 chaining values on the Go heap, where they are
+// never zeroed. The call counts as one retrieval.
+func (s *SecureStorage) KeyDerivation(label []byte, outputSize int) (*SecureStorage, error) {
+	if outputSize <= 0 || outputSize > maxDerivedKeySize {
+		return nil, fmt.Errorf("invalid output size %d (max: %d)", outputSize, maxDerivedKeySize)
+	}
+	if err := checkRaw(s); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.length == 0 {
+		return nil, fmt.Errorf("storage is empty")
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	derived, err := NewSecureStorage(outputSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deriveLocked(derived, label); err != nil {
+		derived.Destroy()
+		return nil, err
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return derived, nil
+}
+
+// deriveLocked fills all of dst, which nothing else references yet, with
+// HKDF output keyed by the stored content.
+func (s *SecureStorage) deriveLocked(dst *SecureStorage, label []byte) error {
+	scratch, err := NewSecureBuffer(2 * sha256.Size)
+	if err != nil {
+		return err
+	}
+	defer scratch.Close()
+	prk := scratch.Bytes()[:0:sha256.Size]
+	tail := scratch.Bytes()[sha256.Size:sha256.Size]
+
+	// Extract: PRK = HMAC(zero salt, content)
+	err = s.withViewLocked(func(view []byte) error {
+		mac := hmac.New(sha256.New, make([]byte, sha256.Size))
+		mac.Write(view[:s.length])
+		prk = mac.Sum(prk)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Expand: T(i) = HMAC(PRK, T(i-1) | label | i)
+	err = dst.withViewLocked(func(out []byte) error {
+		mac := hmac.New(sha256.New, prk)
+		var prev []byte
+		counter := []byte{0}
+		for off := 0; off < len(out); off += len(prev) {
+			counter[0]++
+			mac.Reset()
+			mac.Write(prev)
+			mac.Write(label)
+			mac.Write(counter)
+			if len(out)-off >= sha256.Size {
+				prev = mac.Sum(out[off:off])
+			} else {
+				// Too short for a full block; finish in scratch memory.
+				prev = mac.Sum(tail)
+				copy(out[off:], prev)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	dst.length = dst.size
+	dst.writeOff = dst.size
+	return dst.retagLocked()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SecureKeyRing holds several generations of a key, each in its own
+// SecureStorage, indexed by key ID. One of them is the active key used
+// for new data; the others stay available to decrypt data written under
+// them. It is safe for concurrent use by multiple goroutines.
+type SecureKeyRing struct {
+	mu        sync.RWMutex
+	opts      []Option
+	keys      map[uint32]*SecureStorage
+	active    uint32
+	hasActive bool
+}
+
+// NewSecureKeyRing returns an empty key ring whose keys are created with
+// opts.
+func NewSecureKeyRing(opts ...Option) *SecureKeyRing {
+	return &SecureKeyRing{
+		opts: opts,
+		keys: make(map[uint32]*SecureStorage),
+	}
+}
+
+// Add stores data as key id in a new storage of size bytes. The first key
+// added becomes the active key. It returns ErrKeyExists if id is already
+// in the ring.
+func (r *SecureKeyRing) Add(id uint32, data []byte, size int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[id]; ok {
+		return fmt.Errorf("%w: %d", ErrKeyExists, id)
+	}
+
+	s, err := NewSecureStorageFromBytes(data, size, r.opts...)
+	if err != nil {
+		return err
+	}
+	r.keys[id] = s
+	if !r.hasActive {
+		r.active = id
+		r.hasActive = true
+	}
+	return nil
+}
+
+// Get returns the storage holding key id, or ErrKeyNotFound. The storage
+// remains owned by the ring and must not be destroyed by the caller.
+func (r *SecureKeyRing) Get(id uint32) (*SecureStorage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrKeyNotFound, id)
+	}
+	return s, nil
+}
+
+// Remove destroys key id and drops it from the ring. It returns
+// ErrKeyNotFound if id is not in the ring, and ErrActiveKey if id is the
+// active key; activate another key first.
+func (r *SecureKeyRing) Remove(id uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.keys[id]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrKeyNotFound, id)
+	}
+	if r.hasActive && r.active == id {
+		return fmt.Errorf("%w: %d", ErrActiveKey, id)
+	}
+
+	s.Destroy()
+	delete(r.keys, id)
+	return nil
+}
+
+// ActiveID returns the ID of the active key, or 0 if the ring is empty.
+func (r *SecureKeyRing) ActiveID() uint32 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.active
+}
+
+// SetActiveID makes key id the active key. It returns ErrKeyNotFound if
+// id is not in the ring.
+func (r *SecureKeyRing) SetActiveID(id uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrKeyNotFound, id)
+	}
+	r.active = id
+	r.hasActive = true
+	return nil
+}
+
+// Close destroys every key and empties the ring.
+func (r *SecureKeyRing) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, s := range r.keys {
+		s.Destroy()
+		delete(r.keys, id)
+	}
+	r.active = 0
+	r.hasActive = false
+}
@@ -0,0 +1,86 @@
+//go:build lseco_awskms
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// WithAWSKMSKey enables envelope encryption under the AWS KMS key keyID,
+// which may be a key ID, key ARN, or alias. Every Store calls
+// GenerateDataKey, seals the content with AES-256-GCM under the returned
+// data key, which is only ever held in a temporary locked buffer, and
+// keeps just the encrypted data key and the ciphertext. Every retrieval
+// calls Decrypt to recover the data key and decrypts locally, so a memory
+// dump alone reveals nothing without access to the KMS key. Store,
+// Retrieve, RetrieveAll, and RetrieveBuffer stay transparent, and Used
+// reports the plaintext length; methods that work on the raw bytes, such
+// as ReadAt, Write, and Compare, return ErrEncodedContent.
+//
+// The storage size must leave room for the envelope: the encrypted data
+// key, under 200 bytes for a symmetric KMS key, plus 30 bytes of header,
+// nonce, and tag. WithCompress cannot be combined with it. KMS requests
+// are made with context.Background, so bound them with timeouts on the
+// client; they run without the storage lock held and with its pages
+// unmapped, so a slow request does not block other callers. The SDK
+// returns the plaintext data key in a Go slice, which is copied into
+// locked memory and zeroed; copies inside the SDK and its TLS buffers
+// are out of lseco's reach.
+//
+// WithAWSKMSKey is only available when built with -tags lseco_awskms.
+func WithAWSKMSKey(client *kms.Client, keyID string) Option {
+	return func(c *storageConfig) {
+		c.keyWrapper = &awsKMSWrapper{client: client, keyID: keyID}
+	}
+}
+
+// awsKMSWrapper is the keyWrapper installed by WithAWSKMSKey.
+type awsKMSWrapper struct {
+	client *kms.Client
+	keyID  string
+}
+
+func (w *awsKMSWrapper) generateDataKey(dst []byte) ([]byte, error) {
+	if w.client == nil {
+		return nil, fmt.Errorf("aws kms client is nil")
+	}
+	out, err := w.client.GenerateDataKey(context.Background(), &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(w.keyID),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer SecureZero(out.Plaintext)
+
+	if len(out.Plaintext) != len(dst) {
+		return nil, fmt.Errorf("aws kms returned a %d-byte data key, want %d", len(out.Plaintext), len(dst))
+	}
+	copy(dst, out.Plaintext)
+	return out.CiphertextBlob, nil
+}
+
+func (w *awsKMSWrapper) decryptDataKey(encrypted, dst []byte) error {
+	if w.client == nil {
+		return fmt.Errorf("aws kms client is nil")
+	}
+	out, err := w.client.Decrypt(context.Background(), &kms.DecryptInput{
+		CiphertextBlob: encrypted,
+		KeyId:          aws.String(w.keyID),
+	})
+	if err != nil {
+		return err
+	}
+	defer SecureZero(out.Plaintext)
+
+	if len(out.Plaintext) != len(dst) {
+		return fmt.Errorf("aws kms returned a %d-byte data key, want %d", len(out.Plaintext), len(dst))
+	}
+	copy(dst, out.Plaintext)
+	return nil
+}
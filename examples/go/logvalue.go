@@ -0,0 +1,10 @@
+package main
+
+import "log/slog"
+
+// LogValue implements slog.LogValuer, so a storage passed to a structured
+// logger is logged as a fixed placeholder instead of its fields, which
+// include the C handle address. For development builds, see DebugLogValue.
+func (s *SecureStorage) LogValue() slog.Value {
+	return slog.StringValue("[REDACTED SecureStorage]")
+}
@@ -0,0 +1,23 @@
+//go:build lseco_debug
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// DebugLogValue returns the storage's size and C handle address as a
+// slog group, never its content. Handle addresses help correlate logs
+// with the C side while debugging but should not appear in production
+// logs, so DebugLogValue is only available when built with
+// -tags lseco_debug.
+func (s *SecureStorage) DebugLogValue() slog.Value {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slog.GroupValue(
+		slog.Int("size", s.size),
+		slog.String("handle", fmt.Sprintf("%p", s.handle)),
+	)
+}
@@ -0,0 +1,209 @@
+// Package lsecobench provides standard testing.B benchmarks for lseco
+// secure storages, so performance regressions can be caught with
+// `go test -bench` or testing.Benchmark.
+//
+// The benchmarks are written against small interfaces rather than the
+// concrete SecureStorage type, which lives in a main package and cannot
+// be imported. Each one reports allocations per operation, and
+// BenchmarkStore, BenchmarkRetrieve, and BenchmarkPool fail if their
+// steady-state path allocates at all.
+package lsecobench
+
+import (
+	"testing"
+	"time"
+)
+
+// Sizes used by every benchmark.
+const (
+	StorageSize = 64 // bytes allocated per storage
+	PayloadSize = 32 // bytes stored and retrieved per operation
+)
+
+// Storage is the part of *SecureStorage exercised by the benchmarks.
+// Retrieval is measured through ReadAt, which fills a caller-supplied
+// buffer; Retrieve returns a new slice and so always allocates once.
+type Storage interface {
+	Store(data []byte) error
+	ReadAt(p []byte, off int64) (int, error)
+	Destroy()
+}
+
+// Copier is a Storage that can copy another one in constant time.
+type Copier[S any] interface {
+	Storage
+	ConstantTimeCopy(src S) error
+}
+
+// Pool is the part of *SecureStoragePool exercised by BenchmarkPool.
+type Pool[S Storage] interface {
+	Get() (S, error)
+	Put(s S)
+}
+
+// BenchmarkStore measures Store of PayloadSize bytes into a storage from
+// newStorage.
+func BenchmarkStore[S Storage](b *testing.B, newStorage func(size int) (S, error)) {
+	s := mustStorage(b, newStorage)
+	defer s.Destroy()
+	data := payload()
+
+	store := func() {
+		if err := s.Store(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+	requireZeroAllocs(b, "Store", store)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		store()
+	}
+}
+
+// BenchmarkRetrieve measures reading PayloadSize bytes back from a
+// storage from newStorage.
+func BenchmarkRetrieve[S Storage](b *testing.B, newStorage func(size int) (S, error)) {
+	s := mustStorage(b, newStorage)
+	defer s.Destroy()
+	if err := s.Store(payload()); err != nil {
+		b.Fatal(err)
+	}
+	buf := make([]byte, PayloadSize)
+
+	retrieve := func() {
+		if _, err := s.ReadAt(buf, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+	requireZeroAllocs(b, "ReadAt", retrieve)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		retrieve()
+	}
+}
+
+// BenchmarkCreateDestroy measures creating and destroying a storage,
+// which includes the mlock and munlock system calls. Creation allocates
+// the Go wrapper, so this benchmark reports allocations but does not
+// require zero.
+func BenchmarkCreateDestroy[S Storage](b *testing.B, newStorage func(size int) (S, error)) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s, err := newStorage(StorageSize)
+		if err != nil {
+			b.Fatal(err)
+		}
+		s.Destroy()
+	}
+}
+
+// BenchmarkPool measures a Get, Store, Put cycle on p, which must hand
+// out storages of at least StorageSize bytes.
+func BenchmarkPool[S Storage](b *testing.B, p Pool[S]) {
+	data := payload()
+
+	cycle := func() {
+		s, err := p.Get()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := s.Store(data); err != nil {
+			b.Fatal(err)
+		}
+		p.Put(s)
+	}
+	requireZeroAllocs(b, "pool cycle", cycle)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cycle()
+	}
+}
+
+// BenchmarkConstantTimeCopy measures ConstantTimeCopy of PayloadSize
+// bytes, alternating between an all-zero and an all-0xff source in
+// batches, and reports the mean time per copy for each source as
+// ns/copy-zero and ns/copy-ones, and their difference relative to the
+// faster one as skew-%. A constant-time copy keeps skew-% within timer
+// noise; it is reported rather than enforced, since the noise floor
+// depends on the machine.
+func BenchmarkConstantTimeCopy[S Copier[S]](b *testing.B, newStorage func(size int) (S, error)) {
+	dst := mustStorage(b, newStorage)
+	defer dst.Destroy()
+
+	var sources [2]S
+	for i, fill := range []byte{0x00, 0xff} {
+		sources[i] = mustStorage(b, newStorage)
+		defer sources[i].Destroy()
+		data := make([]byte, PayloadSize)
+		for j := range data {
+			data[j] = fill
+		}
+		if err := sources[i].Store(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	const batch = 64
+	var elapsed [2]time.Duration
+	var copies [2]int
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; {
+		i := (n / batch) % 2
+		count := min(batch, b.N-n)
+
+		start := time.Now()
+		for range count {
+			if err := dst.ConstantTimeCopy(sources[i]); err != nil {
+				b.Fatal(err)
+			}
+		}
+		elapsed[i] += time.Since(start)
+		copies[i] += count
+		n += count
+	}
+	b.StopTimer()
+
+	if copies[0] == 0 || copies[1] == 0 {
+		return
+	}
+	zero := float64(elapsed[0].Nanoseconds()) / float64(copies[0])
+	ones := float64(elapsed[1].Nanoseconds()) / float64(copies[1])
+	b.ReportMetric(zero, "ns/copy-zero")
+	b.ReportMetric(ones, "ns/copy-ones")
+	b.ReportMetric(100*(max(zero, ones)-min(zero, ones))/min(zero, ones), "skew-%")
+}
+
+func mustStorage[S Storage](b *testing.B, newStorage func(size int) (S, error)) S {
+	b.Helper()
+
+	s, err := newStorage(StorageSize)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return s
+}
+
+func payload() []byte {
+	data := make([]byte, PayloadSize)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+// requireZeroAllocs fails b if fn allocates once warmed up.
+func requireZeroAllocs(b *testing.B, name string, fn func()) {
+	b.Helper()
+
+	if n := testing.AllocsPerRun(100, fn); n != 0 {
+		b.Fatalf("%s allocates %.1f times per call, want 0", name, n)
+	}
+}
@@ -8,38 +8,335 @@ package main
 */
 import "C"
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"os"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 	"unsafe"
+
+	"golang.org/x/time/rate"
 )
 
-// SecureStorage wraps lseco_handle_t with Go-friendly interface
+// SecureStorage wraps lseco_handle_t with Go-friendly interface.
+// It is safe for concurrent use by multiple goroutines.
 type SecureStorage struct {
-	handle C.lseco_handle_t
-	size   int
+	// mu guards every field below. Any call into the C layer takes the
+	// write lock, even for reads: the C layer revokes page access after
+	// each copy, so two overlapping reads would fault.
+	mu storageMutex
+
+	handle    C.lseco_handle_t
+	size      int
+	alignment int // 0 means page alignment, see NewSecureStorageAligned
+	length    int // bytes of valid data, grown by Store and Write
+
+	plainLength int // plaintext length of encoded content, see storageConfig.encoded
+
+	// Stream cursors used by Read and Write
+	readOff  int
+	writeOff int
+
+	slots slotIndex // named slots, see StoreNamed
+
+	cfg        storageConfig
+	retrievals int           // successful retrievals so far, see WithMaxRetrievals
+	limiter    *rate.Limiter // nil means unlimited, see WithRetrievalRateLimit
+
+	integrityKey []byte                 // HMAC key for the tag after the data, see retagLocked
+	mac          hash.Hash              // keyed with integrityKey, see tagMACLocked
+	tagScratch   [integrityTagSize]byte // expected tag, see verifyLocked
+	mapped       unsafe.Pointer         // lseco_acquire result, see mapLocked
+
+	encryptionSeed *SecureBuffer // created by the first Encrypt or DecryptInto
+
+	group    *SecureStorageGroup // nil unless added to a SecureStorageGroup
+	watchers sync.Map            // chan struct{} keys, see Watch
+
+	snapshots    []snapshot // oldest first, see Snapshot
+	lastSnapshot uint64     // token of the most recent snapshot
+
+	// TTL state, see StoreWithTTL. expiryGen invalidates scheduled
+	// expiries whenever the content is replaced.
+	expiresAt time.Time
+	expiryGen uint64
+	expiry    *expiryEntry // nil until first scheduled
+
+	rotations int // see Rotate
+
+	metadata map[string]string // non-sensitive annotations, see AttachMetadata
+}
+
+// NewSecureStorage creates a new secure storage. It returns
+// ErrSizeExceedsMax if size is above the WithMaxSize or SetGlobalMaxSize
+// cap, DefaultMaxSize unless changed.
+func NewSecureStorage(size int, opts ...Option) (*SecureStorage, error) {
+	return newSecureStorage(size, 0, newStorageConfig(opts))
+}
+
+// NewSecureStorageAligned creates a storage whose data starts at a
+// multiple of alignment, for primitives that require aligned input such
+// as AES-NI (16) or AVX-512 (64). It returns ErrInvalidAlignment unless
+// alignment is a positive power of two. The alignment is kept across
+// Resize.
+func NewSecureStorageAligned(size, alignment int, opts ...Option) (*SecureStorage, error) {
+	if alignment <= 0 || alignment&(alignment-1) != 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidAlignment, alignment)
+	}
+	return newSecureStorage(size, alignment, newStorageConfig(opts))
 }
 
-// NewSecureStorage creates a new secure storage
-func NewSecureStorage(size int) (*SecureStorage, error) {
-	handle := C.lseco_create(C.size_t(size))
-	if handle == nil {
+func newSecureStorage(size, alignment int, cfg storageConfig) (*SecureStorage, error) {
+	if size <= 0 {
 		return nil, fmt.Errorf("failed to create secure storage")
 	}
+	if err := cfg.checkSize(size); err != nil {
+		return nil, err
+	}
+
+	key, err := newIntegrityKey()
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := cfg.createHandle(size+integrityTagSize, alignment)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.testMode {
+		warnTestMode(size)
+	}
+
+	s := &SecureStorage{
+		mu:           storageMutex{detect: cfg.detectConcurrency},
+		handle:       handle,
+		size:         size,
+		alignment:    alignment,
+		cfg:          cfg,
+		limiter:      newRetrievalLimiter(cfg.retrievalRate),
+		integrityKey: key,
+	}
+	runtime.SetFinalizer(s, finalizeStorage)
+	cfg.metrics.allocated(size)
+
+	if cfg.preZero {
+		err = s.wipeLocked(0, size)
+	} else {
+		err = s.retagLocked()
+	}
+	if err != nil {
+		s.Destroy()
+		return nil, err
+	}
 
-	return &SecureStorage{
-		handle: handle,
-		size:   size,
-	}, nil
+	return s, nil
+}
+
+// NewSecureStorageFromBytes creates a storage of size bytes pre-loaded
+// with data. data itself stays on the Go heap: zero it as soon as the
+// call returns, or pass WithZeroSource(true) to have it zeroed here,
+// whether or not the call succeeds.
+func NewSecureStorageFromBytes(data []byte, size int, opts ...Option) (*SecureStorage, error) {
+	cfg := newStorageConfig(opts)
+	if cfg.zeroSource {
+		defer SecureZero(data)
+	}
+
+	s, err := NewSecureStorage(size, opts...)
+	if err != nil {
+		return nil, err
+	}
+	key, err := cfg.generateEnvelopeKey()
+	if err != nil {
+		s.Destroy()
+		return nil, err
+	}
+	defer key.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.storeLocked(data, key); err != nil {
+		s.abandonLocked()
+		return nil, err
+	}
+	s.record(opStore)
+	return s, nil
+}
+
+// NewSecureStorageFromReader creates a storage of size bytes and fills it
+// from r until size bytes have been read or r reports io.EOF; Used
+// reports how many arrived, and the remainder is zeroed. Use it to load
+// secrets from os.Stdin or an *os.File without holding them in a Go
+// slice.
+//
+// Data is copied through a locked 4096-byte staging buffer rather than a
+// stack array, which would escape to the heap once passed to r.Read. The
+// buffer is zeroed before the call returns, so r must not retain the
+// slices it is given, as io.Reader requires.
+func NewSecureStorageFromReader(r io.Reader, size int, opts ...Option) (*SecureStorage, error) {
+	s, err := NewSecureStorage(size, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.readFromLocked(r); err != nil {
+		s.abandonLocked()
+		return nil, err
+	}
+	s.record(opStore)
+	return s, nil
+}
+
+func (s *SecureStorage) readFromLocked(r io.Reader) error {
+	n, err := s.fillFromLocked(r)
+	if err != nil {
+		return err
+	}
+	if err := s.wipeLocked(n, s.size-n); err != nil {
+		return err
+	}
+
+	s.length = n
+	s.readOff = 0
+	s.writeOff = n
+	return s.retagLocked()
+}
+
+// fillFromLocked copies from r through a locked staging buffer until the
+// storage is full or r returns io.EOF, and returns the number of bytes
+// copied. It leaves the stored length and the tag to the caller.
+func (s *SecureStorage) fillFromLocked(r io.Reader) (int, error) {
+	staging, err := NewSecureBuffer(stagingChunkSize)
+	if err != nil {
+		return 0, err
+	}
+	defer staging.Close()
+	chunk := staging.Bytes()
+
+	n := 0
+	for n < s.size {
+		got, err := r.Read(chunk[:min(len(chunk), s.size-n)])
+		if got > 0 {
+			result := C.lseco_store_at(s.handle, C.size_t(n), unsafe.Pointer(&chunk[0]), C.size_t(got))
+			if result != C.LSECO_SUCCESS {
+				return n, resultError("store", result)
+			}
+			n += got
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// discardPartialLocked zeroes the first n bytes written by a failed fill,
+// along with the previous content, leaves the storage empty, and returns
+// cause joined with any error from doing so.
+func (s *SecureStorage) discardPartialLocked(n int, cause error) error {
+	if err := s.wipeLocked(0, max(n, s.length)); err != nil {
+		return errors.Join(cause, err)
+	}
+	s.length = 0
+	s.readOff = 0
+	s.writeOff = 0
+	s.clearExpiryLocked()
+	return errors.Join(cause, s.retagLocked())
+}
+
+// abandonLocked frees a storage whose constructor failed after
+// allocation.
+func (s *SecureStorage) abandonLocked() {
+	C.lseco_destroy(s.handle)
+	s.handle = nil
+	runtime.SetFinalizer(s, nil)
+	s.cfg.metrics.freed(s.size)
 }
 
 // Store stores data in secure memory
 func (s *SecureStorage) Store(data []byte) error {
+	return s.store(context.Background(), data)
+}
+
+func (s *SecureStorage) store(ctx context.Context, data []byte) (err error) {
+	end := s.startSpan(ctx, opStore)
+	defer func() { end(len(data), err) }()
+
+	key, err := s.cfg.generateEnvelopeKey()
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.storeLocked(data, key); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
+
+// TryStore stores data like Store, but returns ErrLocked instead of
+// waiting if another operation is in progress.
+func (s *SecureStorage) TryStore(data []byte) error {
+	key, err := s.cfg.generateEnvelopeKey()
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	if !s.mu.TryLock() {
+		return ErrLocked
+	}
+	defer s.mu.Unlock()
+
+	if err := s.storeLocked(data, key); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
+
+// storeLocked stores data, encoding it as configured. key is the data key
+// from generateEnvelopeKey for an envelope encrypted storage, or nil.
+func (s *SecureStorage) storeLocked(data []byte, key *envelopeKey) error {
 	if len(data) == 0 {
 		return fmt.Errorf("data cannot be empty")
 	}
+	if s.cfg.keyWrapper != nil {
+		return s.storeEnvelopeLocked(data, key)
+	}
+	if s.cfg.compression != 0 {
+		return s.storeCompressedLocked(data)
+	}
+	return s.storeRawLocked(data)
+}
+
+// storeRawLocked stores data as is, bypassing WithCompress and envelope
+// encryption.
+func (s *SecureStorage) storeRawLocked(data []byte) error {
 	if len(data) > s.size {
 		return fmt.Errorf("data size %d exceeds storage size %d", len(data), s.size)
 	}
+	// Bytes past len(data), such as named slots, are kept and retagged.
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
 
 	result := C.lseco_store(
 		s.handle,
@@ -48,18 +345,80 @@ func (s *SecureStorage) Store(data []byte) error {
 	)
 
 	if result != C.LSECO_SUCCESS {
-		msg := C.GoString(C.lseco_error_string(result))
-		return fmt.Errorf("store failed: %s", msg)
+		return resultError("store", result)
 	}
 
+	s.length = len(data)
+	s.readOff = 0
+	s.writeOff = len(data)
+	s.clearExpiryLocked()
+
+	if err := s.retagLocked(); err != nil {
+		return err
+	}
+	s.notifyWatchersLocked()
 	return nil
 }
 
 // Retrieve retrieves data from secure memory
 func (s *SecureStorage) Retrieve(length int) ([]byte, error) {
+	return s.retrieve(context.Background(), length)
+}
+
+func (s *SecureStorage) retrieve(ctx context.Context, length int) (_ []byte, err error) {
+	end := s.startSpan(ctx, opRetrieve)
+	defer func() { end(length, err) }()
+
+	key, err := s.lockWithDataKey(false)
+	if err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+	defer key.Close()
+
+	data, err := s.retrieveLocked(length, key)
+	if err != nil {
+		return nil, err
+	}
+	s.record(opRetrieve)
+	return data, nil
+}
+
+// TryRetrieve retrieves data like Retrieve, but returns ErrLocked
+// instead of waiting if another operation is in progress.
+func (s *SecureStorage) TryRetrieve(length int) ([]byte, error) {
+	key, err := s.lockWithDataKey(true)
+	if err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+	defer key.Close()
+
+	data, err := s.retrieveLocked(length, key)
+	if err != nil {
+		return nil, err
+	}
+	s.record(opRetrieve)
+	return data, nil
+}
+
+// retrieveLocked copies length bytes of the content onto the Go heap.
+// key is the data key from lockWithDataKey, or nil.
+func (s *SecureStorage) retrieveLocked(length int, key *envelopeKey) ([]byte, error) {
+	if s.cfg.encoded() {
+		plain, err := s.retrieveDecodedLocked(length, key)
+		if err != nil {
+			return nil, err
+		}
+		defer plain.Close()
+		return bytes.Clone(plain.Bytes()), nil
+	}
 	if length == 0 || length > s.size {
 		return nil, fmt.Errorf("invalid length %d (max: %d)", length, s.size)
 	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
 
 	buffer := make([]byte, length)
 	result := C.lseco_retrieve(
@@ -69,18 +428,214 @@ func (s *SecureStorage) Retrieve(length int) ([]byte, error) {
 	)
 
 	if result != C.LSECO_SUCCESS {
-		msg := C.GoString(C.lseco_error_string(result))
-		return nil, fmt.Errorf("retrieve failed: %s", msg)
+		return nil, resultError("retrieve", result)
 	}
 
+	s.countRetrievalLocked()
 	return buffer, nil
 }
 
+// RetrieveAll retrieves all stored data, as reported by Len, so callers
+// do not have to track the length themselves.
+func (s *SecureStorage) RetrieveAll() ([]byte, error) {
+	key, err := s.lockWithDataKey(false)
+	if err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+	defer key.Close()
+
+	if s.length == 0 {
+		return nil, fmt.Errorf("no data stored")
+	}
+
+	data, err := s.retrieveLocked(s.usedLocked(), key)
+	if err != nil {
+		return nil, err
+	}
+	s.record(opRetrieve)
+	return data, nil
+}
+
+// checkRetrievalLocked reports whether another retrieval is allowed and
+// the content passes its integrity check. Callers increment s.retrievals
+// once the copy succeeds.
+func (s *SecureStorage) checkRetrievalLocked() error {
+	if err := s.retrievableLocked(); err != nil {
+		return err
+	}
+	if s.limiter != nil && !s.limiter.Allow() {
+		return ErrRateLimitExceeded
+	}
+	return s.verifyLocked()
+}
+
+// retrievableLocked is checkRetrievalLocked without the rate limit and
+// integrity check: it reports whether the content has expired or used up
+// its retrievals.
+func (s *SecureStorage) retrievableLocked() error {
+	if s.expiredLocked() {
+		return ErrExpired
+	}
+	if s.cfg.maxRetrievals > 0 && s.retrievals >= s.cfg.maxRetrievals {
+		return ErrRetrievalLimitExceeded
+	}
+	return nil
+}
+
+// countRetrievalLocked counts one successful retrieval and wipes the
+// storage once the last retrieval allowed by WithMaxRetrievals is used.
+func (s *SecureStorage) countRetrievalLocked() {
+	s.retrievals++
+	if s.cfg.maxRetrievals > 0 && s.retrievals == s.cfg.maxRetrievals {
+		// Best effort: even if the wipe fails, checkRetrievalLocked
+		// rejects every further retrieval.
+		if s.wipeAllLocked() == nil {
+			s.clearExpiryLocked()
+		}
+	}
+}
+
+// RetrievalsRemaining returns how many more retrievals WithMaxRetrievals
+// allows, or -1 if retrievals are unlimited.
+func (s *SecureStorage) RetrievalsRemaining() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.cfg.maxRetrievals <= 0 {
+		return -1
+	}
+	return max(s.cfg.maxRetrievals-s.retrievals, 0)
+}
+
+// Wipe securely zeroes the whole storage in place and resets the stored
+// length, stream cursors, and named slots, destroys all snapshots, and
+// cancels a pending TTL expiry. Unlike Destroy, the allocation stays
+// valid, so the storage can be reused by a subsequent Store.
+func (s *SecureStorage) Wipe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.wipeAllLocked(); err != nil {
+		return err
+	}
+	s.clearExpiryLocked()
+	return nil
+}
+
+func (s *SecureStorage) wipeAllLocked() error {
+	if err := s.wipeLocked(0, s.size); err != nil {
+		return err
+	}
+
+	s.length = 0
+	s.readOff = 0
+	s.writeOff = 0
+	s.slots = slotIndex{}
+	s.metadata = nil
+	s.dropSnapshotsLocked()
+	return nil
+}
+
 // Destroy securely destroys the storage
 func (s *SecureStorage) Destroy() {
+	end := s.startSpan(context.Background(), opDestroy)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer end(s.size, nil)
+
+	s.dropSnapshotsLocked()
+	s.closeWatchersLocked()
+	s.clearExpiryLocked()
+	s.metadata = nil
+	if s.encryptionSeed != nil {
+		s.encryptionSeed.Close()
+		s.encryptionSeed = nil
+	}
 	if s.handle != nil {
 		C.lseco_destroy(s.handle)
 		s.handle = nil
+		runtime.SetFinalizer(s, nil)
+		s.cfg.metrics.freed(s.size)
+		s.record(opDestroy)
+	}
+}
+
+// IsDestroyed reports whether Destroy has been called, so callers can
+// check a storage they do not own before using it.
+func (s *SecureStorage) IsDestroyed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.handle == nil
+}
+
+// Size returns the capacity of the storage: the size passed to
+// NewSecureStorage, unless it was changed by Resize or UnmarshalBinary.
+func (s *SecureStorage) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.size
+}
+
+// Used returns the number of bytes of valid data, as set by the last
+// successful Store and extended by Write. It is zero after Wipe. With
+// WithCompress, it is the decompressed length.
+func (s *SecureStorage) Used() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.usedLocked()
+}
+
+func (s *SecureStorage) usedLocked() int {
+	if s.cfg.encoded() && s.length > 0 {
+		return s.plainLength
+	}
+	return s.length
+}
+
+// Len returns the length of the stored data, like len on a slice: it is
+// set by Store, extended by Write, and reset to zero by Wipe. It is the
+// same as Used, and RetrieveAll reads exactly Len bytes.
+func (s *SecureStorage) Len() int {
+	return s.Used()
+}
+
+// Cap returns the capacity of the storage, like cap on a slice. It is the
+// same as Size.
+func (s *SecureStorage) Cap() int {
+	return s.Size()
+}
+
+// lockPair write-locks two storages in a consistent order so concurrent
+// calls with swapped arguments cannot deadlock. a and b may be the same
+// storage. The returned function releases both locks.
+func lockPair(a, b *SecureStorage) (unlock func()) {
+	if a == b {
+		a.mu.Lock()
+		return a.mu.Unlock
+	}
+
+	first, second := a, b
+	if uintptr(unsafe.Pointer(second)) < uintptr(unsafe.Pointer(first)) {
+		first, second = second, first
+	}
+	first.mu.Lock()
+	second.mu.Lock()
+
+	return func() {
+		// Both locks must be released before either group wipe runs,
+		// since a group may hold both storages.
+		wipeSecond := second.mu.takePendingWipe()
+		wipeFirst := first.mu.takePendingWipe()
+		second.mu.Unlock()
+		first.mu.Unlock()
+		wipeSecond.wipeAll()
+		if wipeFirst != wipeSecond {
+			wipeFirst.wipeAll()
+		}
 	}
 }
 
@@ -287,6 +842,11 @@ func demonstrateEdgeCases() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		runBenchmarks()
+		return
+	}
+
 	fmt.Println("\n" + strings.Repeat("=", 50))
 	fmt.Println("  Lseco Go Example - Comprehensive Test")
 	fmt.Println(strings.Repeat("=", 50) + "\n")
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// newTestStorage creates a storage that is destroyed when the test ends.
+func newTestStorage(t *testing.T, size int, opts ...Option) *SecureStorage {
+	t.Helper()
+
+	s, err := NewSecureStorage(size, opts...)
+	if err != nil {
+		t.Fatalf("NewSecureStorage(%d) failed: %v", size, err)
+	}
+	t.Cleanup(s.Destroy)
+	return s
+}
+
+// mustStore stores data in s or fails the test.
+func mustStore(t *testing.T, s *SecureStorage, data []byte) {
+	t.Helper()
+
+	if err := s.Store(data); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+}
+
+// requireContent fails the test unless RetrieveAll returns want.
+func requireContent(t *testing.T, s *SecureStorage, want []byte) {
+	t.Helper()
+
+	got, err := s.RetrieveAll()
+	if err != nil {
+		t.Fatalf("RetrieveAll failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("RetrieveAll() = %q, want %q", got, want)
+	}
+}
+
+func TestStoreRetrieve(t *testing.T) {
+	s := newTestStorage(t, 32)
+	mustStore(t, s, []byte("secret"))
+
+	got, err := s.Retrieve(6)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if string(got) != "secret" {
+		t.Fatalf("Retrieve(6) = %q, want %q", got, "secret")
+	}
+	if s.Used() != 6 {
+		t.Fatalf("Used() = %d, want 6", s.Used())
+	}
+
+	if err := s.Wipe(); err != nil {
+		t.Fatalf("Wipe failed: %v", err)
+	}
+	if s.Used() != 0 {
+		t.Fatalf("Used() after Wipe = %d, want 0", s.Used())
+	}
+}
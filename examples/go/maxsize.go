@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// DefaultMaxSize is the cap on storage sizes in effect until
+// SetGlobalMaxSize is called: 1 MiB, far above any key or credential but
+// low enough that a size taken from untrusted input, such as a length
+// field in a network message, cannot exhaust RLIMIT_MEMLOCK.
+const DefaultMaxSize = 1 << 20
+
+var globalMaxSize atomic.Int64
+
+func init() {
+	globalMaxSize.Store(DefaultMaxSize)
+}
+
+// SetGlobalMaxSize sets the largest size, in bytes, accepted when a
+// storage is created, resized, or unmarshaled, unless overridden by
+// WithMaxSize. Zero or a negative n removes the cap.
+func SetGlobalMaxSize(n int) {
+	globalMaxSize.Store(int64(n))
+}
+
+// WithMaxSize caps the size of this storage at n bytes instead of the
+// SetGlobalMaxSize cap, e.g. to allow one large storage or to hold one fed
+// by untrusted input to a tighter limit. Zero or a negative n keeps the
+// global cap.
+func WithMaxSize(n int) Option {
+	return func(c *storageConfig) {
+		c.maxSize = n
+	}
+}
+
+// checkSize returns ErrSizeExceedsMax if size is above the cap for the
+// storage, before anything is allocated.
+func (c *storageConfig) checkSize(size int) error {
+	limit := int64(c.maxSize)
+	if limit <= 0 {
+		limit = globalMaxSize.Load()
+	}
+	if limit > 0 && int64(size) > limit {
+		return fmt.Errorf("%w: %d > %d", ErrSizeExceedsMax, size, limit)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import "os"
+
+// MemInfo describes the memory behind a storage and the process's mlock
+// budget, for debugging allocation failures such as ErrLockFailed.
+type MemInfo struct {
+	// IsLocked reports whether lseco mlock-ed the allocation. It is false
+	// once the storage is destroyed, and for WithAllocator storages,
+	// whose allocator is responsible for locking.
+	IsLocked bool
+	// PageCount is the number of pages spanned by the allocation,
+	// including the integrity tag and any WithPageGuard guard pages.
+	PageCount int
+	// MlockUsedBytes is the memory the process currently has locked
+	// (VmLck on Linux), or -1 where it cannot be determined.
+	MlockUsedBytes int64
+	// MlockLimitBytes is the soft RLIMIT_MEMLOCK, -1 if unlimited or
+	// unknown.
+	MlockLimitBytes int64
+}
+
+// MemInfo reports the storage's page usage and the process-wide mlock
+// usage and limit. Process figures are read fresh on every call.
+func (s *SecureStorage) MemInfo() MemInfo {
+	s.mu.RLock()
+	destroyed := s.handle == nil
+	custom := s.cfg.allocator != nil
+	guarded := s.cfg.pageGuard
+	size := s.size + integrityTagSize
+	s.mu.RUnlock()
+
+	info := MemInfo{IsLocked: !destroyed && !custom}
+	if !destroyed {
+		page := os.Getpagesize()
+		info.PageCount = (size + page - 1) / page
+		if guarded {
+			info.PageCount += 2
+		}
+	}
+	info.MlockUsedBytes, info.MlockLimitBytes = processMlock()
+	return info
+}
@@ -0,0 +1,40 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// processMlock returns VmLck from /proc/self/status and the soft
+// RLIMIT_MEMLOCK, each -1 if unavailable.
+func processMlock() (used, limit int64) {
+	used, limit = -1, -1
+
+	if f, err := os.Open("/proc/self/status"); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			value, ok := strings.CutPrefix(scanner.Text(), "VmLck:")
+			if !ok {
+				continue
+			}
+			// The value is reported as "<n> kB".
+			if kb, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64); err == nil {
+				used = kb * 1024
+			}
+			break
+		}
+		f.Close()
+	}
+
+	var rlim unix.Rlimit
+	if unix.Getrlimit(unix.RLIMIT_MEMLOCK, &rlim) == nil && rlim.Cur != unix.RLIM_INFINITY {
+		limit = int64(rlim.Cur)
+	}
+	return used, limit
+}
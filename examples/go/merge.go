@@ -0,0 +1,119 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import "fmt"
+
+// MergeOp selects how MergeSecrets combines two key shares.
+type MergeOp int
+
+const (
+	// MergeOpConcat appends b's content to a's.
+	MergeOpConcat MergeOp = iota
+	// MergeOpXOR XORs a's content with b's, which must be the same length.
+	MergeOpXOR
+)
+
+func (op MergeOp) String() string {
+	switch op {
+	case MergeOpConcat:
+		return "concat"
+	case MergeOpXOR:
+		return "xor"
+	default:
+		return fmt.Sprintf("MergeOp(%d)", int(op))
+	}
+}
+
+// MergeSecrets combines the contents of a and b into a new storage of
+// outputSize bytes, as protocols that derive a working key from two
+// shares do. Both the copy and the XOR run C-to-C between locked regions,
+// so no intermediate value appears on the Go heap. a and b are left
+// unchanged, and each counts one retrieval.
+//
+// It returns ErrSizeMismatch if op is MergeOpXOR and a.Len() != b.Len(),
+// and ErrDestinationTooSmall if outputSize cannot hold the result. The
+// output is created without a's or b's options and must be destroyed.
+func MergeSecrets(a, b *SecureStorage, op MergeOp, outputSize int) (*SecureStorage, error) {
+	if err := checkRaw(a, b); err != nil {
+		return nil, err
+	}
+
+	unlock := lockPair(a, b)
+	defer unlock()
+
+	if a.length == 0 || b.length == 0 {
+		return nil, fmt.Errorf("cannot merge an empty storage")
+	}
+
+	var n int
+	switch op {
+	case MergeOpConcat:
+		n = a.length + b.length
+	case MergeOpXOR:
+		if a.length != b.length {
+			return nil, fmt.Errorf("%w: %d != %d", ErrSizeMismatch, a.length, b.length)
+		}
+		n = a.length
+	default:
+		return nil, fmt.Errorf("unsupported merge operation %v", op)
+	}
+	if outputSize < n {
+		return nil, fmt.Errorf("%w: need %d bytes, have %d", ErrDestinationTooSmall, n, outputSize)
+	}
+
+	if err := a.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+	if b != a {
+		if err := b.checkRetrievalLocked(); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := NewSecureStorage(outputSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := out.mergeLocked(a, b, op); err != nil {
+		out.Destroy()
+		return nil, err
+	}
+
+	a.countRetrievalLocked()
+	a.record(opRetrieve)
+	if b != a {
+		b.countRetrievalLocked()
+		b.record(opRetrieve)
+	}
+	out.record(opStore)
+	return out, nil
+}
+
+// mergeLocked writes the merge of a and b to the start of the unshared
+// storage s and marks it as the content.
+func (s *SecureStorage) mergeLocked(a, b *SecureStorage, op MergeOp) error {
+	result := C.lseco_copy_at(s.handle, 0, a.handle, 0, C.size_t(a.length))
+	if result != C.LSECO_SUCCESS {
+		return resultError("merge", result)
+	}
+
+	n := a.length
+	switch op {
+	case MergeOpConcat:
+		result = C.lseco_copy_at(s.handle, C.size_t(n), b.handle, 0, C.size_t(b.length))
+		n += b.length
+	case MergeOpXOR:
+		result = C.lseco_xor(s.handle, b.handle, C.size_t(n))
+	}
+	if result != C.LSECO_SUCCESS {
+		return resultError("merge", result)
+	}
+
+	s.length = n
+	s.readOff = 0
+	s.writeOff = n
+	return s.retagLocked()
+}
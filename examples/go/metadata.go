@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+)
+
+// AttachMetadata annotates the storage with a key-value pair, such as the
+// service using the secret, when it was generated, or its algorithm,
+// replacing any earlier value for key. Metadata is not secret: it is
+// kept in an ordinary Go map, outside locked memory, and may be logged.
+// It survives Store and Retrieve but is cleared whenever the content is
+// wiped, by Wipe, Seal, expiry, or the last WithMaxRetrievals retrieval.
+func (s *SecureStorage) AttachMetadata(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("metadata key cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.handle == nil {
+		return fmt.Errorf("storage is destroyed")
+	}
+	if s.metadata == nil {
+		s.metadata = make(map[string]string)
+	}
+	s.metadata[key] = value
+	return nil
+}
+
+// Metadata returns the value attached under key, if any.
+func (s *SecureStorage) Metadata(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.metadata[key]
+	return value, ok
+}
+
+// AllMetadata returns a copy of every attached key-value pair. The map
+// is never nil.
+func (s *SecureStorage) AllMetadata() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.metadata == nil {
+		return map[string]string{}
+	}
+	return maps.Clone(s.metadata)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// storageMetrics are the collectors registered by WithMetrics. A nil
+// *storageMetrics records nothing.
+type storageMetrics struct {
+	live       prometheus.Gauge
+	lockedSize prometheus.Gauge
+	operations *prometheus.CounterVec
+}
+
+var (
+	metricsMu    sync.Mutex
+	metricsByReg = make(map[prometheus.Registerer]*storageMetrics)
+)
+
+// WithMetrics records Prometheus metrics for the storage in reg:
+//
+//   - lseco_storages_live: storages created and not yet destroyed
+//   - lseco_locked_bytes: total capacity of those storages, all mlock-ed
+//   - lseco_operations_total{op="store|retrieve|rotate|destroy"}: accesses
+//
+// The collectors are registered once per Registerer and shared by every
+// storage using it. The only label is op, with a fixed set of values, so
+// cardinality stays constant however many storages exist. If reg cannot
+// register the collectors, metrics are silently disabled for the storage.
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(c *storageConfig) {
+		c.metrics = metricsFor(reg)
+	}
+}
+
+func metricsFor(reg prometheus.Registerer) *storageMetrics {
+	if reg == nil {
+		return nil
+	}
+
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	if m, ok := metricsByReg[reg]; ok {
+		return m
+	}
+
+	m := &storageMetrics{
+		live: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "lseco",
+			Name:      "storages_live",
+			Help:      "Number of SecureStorage instances created and not yet destroyed.",
+		}),
+		lockedSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "lseco",
+			Name:      "locked_bytes",
+			Help:      "Total capacity in bytes of live SecureStorage instances, all of it mlock-ed.",
+		}),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "lseco",
+			Name:      "operations_total",
+			Help:      "Successful SecureStorage accesses by operation.",
+		}, []string{"op"}),
+	}
+
+	var err error
+	if m.live, err = register(reg, m.live); err != nil {
+		return nil
+	}
+	if m.lockedSize, err = register(reg, m.lockedSize); err != nil {
+		return nil
+	}
+	if m.operations, err = register(reg, m.operations); err != nil {
+		return nil
+	}
+
+	metricsByReg[reg] = m
+	return m
+}
+
+// register registers c with reg, reusing a collector that is already
+// registered under the same name.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	return c, err
+}
+
+func (m *storageMetrics) observe(op storageOp) {
+	if m == nil {
+		return
+	}
+	m.operations.WithLabelValues(op.String()).Inc()
+}
+
+// allocated records a new allocation of size bytes.
+func (m *storageMetrics) allocated(size int) {
+	if m == nil {
+		return
+	}
+	m.live.Inc()
+	m.lockedSize.Add(float64(size))
+}
+
+// resized records an allocation changing from oldSize to newSize bytes.
+func (m *storageMetrics) resized(oldSize, newSize int) {
+	if m == nil {
+		return
+	}
+	m.lockedSize.Add(float64(newSize - oldSize))
+}
+
+// freed records the release of an allocation of size bytes.
+func (m *storageMetrics) freed(size int) {
+	if m == nil {
+		return
+	}
+	m.live.Dec()
+	m.lockedSize.Sub(float64(size))
+}
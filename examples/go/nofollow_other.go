@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// openNoFollow is zero where the platform has no O_NOFOLLOW. On Windows,
+// the temporary file is still created with O_EXCL, and os.Rename replaces
+// a link at the destination instead of writing through it.
+const openNoFollow = 0
@@ -0,0 +1,9 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// openNoFollow makes os.OpenFile fail if the final path element is a
+// symbolic link.
+const openNoFollow = syscall.O_NOFOLLOW
@@ -0,0 +1,133 @@
+package main
+
+import "log/slog"
+
+// storageConfig collects the settings applied by Option values.
+type storageConfig struct {
+	preZero       bool
+	logger        *slog.Logger
+	maxRetrievals int     // 0 means unlimited
+	retrievalRate float64 // per second, 0 means unlimited
+
+	serializationKey []byte // AES-256 key, see MarshalBinary
+	backupPassphrase []byte // see WithBackupPassphrase
+
+	audit AuditHandler // nil means no auditing
+
+	zeroSource bool
+
+	maxSnapshots int // 0 means defaultMaxSnapshots
+	maxSize      int // 0 means the SetGlobalMaxSize cap
+
+	onExpiry func(*SecureStorage) // nil means wipe right away, see WithOnExpiry
+
+	compression CompressionAlgorithm // 0 means none, see WithCompress
+	keyWrapper  keyWrapper           // nil means no envelope encryption, see envelope.go
+
+	metrics *storageMetrics // nil means no metrics, see WithMetrics
+
+	spans spanStarter // nil means no tracing, see tracing.go
+
+	allocator *customAllocator // nil means malloc and mlock, see WithAllocator
+	pageGuard bool
+	testMode  bool // see WithTestMode
+
+	sgxEnclave   bool // see WithSGXEnclave
+	sgxEnclaveID uint64
+
+	detectConcurrency bool // see WithConcurrencyDetector (lseco_debug builds)
+}
+
+// Option configures a SecureStorage at construction time.
+type Option func(*storageConfig)
+
+// WithPreZero zeroes the whole allocation before NewSecureStorage
+// returns, so stale bytes from a previous owner of the pages can never
+// be read back.
+func WithPreZero(enabled bool) Option {
+	return func(c *storageConfig) {
+		c.preZero = enabled
+	}
+}
+
+// WithPageGuard surrounds the allocation with an inaccessible guard page
+// on each side, via lseco_create_guarded, so a buffer overflow into or
+// out of the storage crashes with SIGSEGV instead of corrupting memory
+// silently, much like ASan redzones. Guard pages are not mlock-ed but
+// cost two pages of address space per storage, tripling it for small
+// secrets, so use it in tests only. Resize keeps the guard pages.
+func WithPageGuard() Option {
+	return func(c *storageConfig) {
+		c.pageGuard = true
+	}
+}
+
+// WithLogger sets the logger used for warnings about this storage, such
+// as a missing Destroy. It overrides the logger set by SetLeakLogger.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *storageConfig) {
+		c.logger = l
+	}
+}
+
+// WithMaxRetrievals limits how many times the content can be read back;
+// n == 1 makes a one-time secret. Every call that copies data out
+// (Retrieve and its variants, RetrieveBuffer, RetrieveNamed, Read)
+// counts as one retrieval. The last allowed retrieval wipes the storage
+// as Wipe does, and later ones return ErrRetrievalLimitExceeded. Zero or
+// a negative n means unlimited.
+func WithMaxRetrievals(n int) Option {
+	return func(c *storageConfig) {
+		c.maxRetrievals = n
+	}
+}
+
+// WithSerializationKey sets the 32-byte AES-256 key used by
+// MarshalBinary and UnmarshalBinary. The key is copied.
+func WithSerializationKey(key []byte) Option {
+	return func(c *storageConfig) {
+		c.serializationKey = append([]byte(nil), key...)
+	}
+}
+
+// WithZeroSource makes NewSecureStorageFromBytes zero the source slice
+// once its content has been loaded.
+func WithZeroSource(enabled bool) Option {
+	return func(c *storageConfig) {
+		c.zeroSource = enabled
+	}
+}
+
+// WithMaxSnapshots sets how many snapshots are retained before the
+// oldest is destroyed. Zero or a negative n keeps the default of 8.
+func WithMaxSnapshots(n int) Option {
+	return func(c *storageConfig) {
+		c.maxSnapshots = n
+	}
+}
+
+func newStorageConfig(opts []Option) storageConfig {
+	var cfg storageConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// encoded reports whether content is stored compressed or envelope
+// encrypted rather than as is, so its plaintext length is kept apart.
+func (c *storageConfig) encoded() bool {
+	return c.compression != 0 || c.keyWrapper != nil
+}
+
+// checkRaw returns ErrEncodedContent if any of storages is encoded, for
+// methods that would otherwise hand out or overwrite the encoded bytes
+// instead of the plaintext.
+func checkRaw(storages ...*SecureStorage) error {
+	for _, s := range storages {
+		if s.cfg.encoded() {
+			return ErrEncodedContent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ReadPassword writes prompt to os.Stderr, reads a line from input, which
+// must be a terminal such as os.Stdin, with echo disabled, and returns it
+// in a new storage sized to fit, created with opts. The intermediate
+// buffer is zeroed before returning, but golang.org/x/term may have
+// copied the input while reading it, and those copies are out of reach.
+// It fails if input is nil or not a terminal, or the line is empty.
+func ReadPassword(input *os.File, prompt string, opts ...Option) (*SecureStorage, error) {
+	if input == nil {
+		return nil, fmt.Errorf("password input cannot be nil")
+	}
+	fd := int(input.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("%s is not a terminal", input.Name())
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	// The newline typed by the user is not echoed either.
+	fmt.Fprintln(os.Stderr)
+	defer SecureZero(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+	if len(password) == 0 {
+		return nil, fmt.Errorf("empty password")
+	}
+
+	return NewSecureStorageFromBytes(password, len(password), opts...)
+}
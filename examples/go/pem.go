@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+)
+
+// PEM headers written by MarshalPEM.
+const (
+	pemSizeHeader = "X-Lseco-Size"
+	pemAlgHeader  = "X-Lseco-Alg"
+)
+
+// MarshalPEM seals the stored content with AES-256-GCM under key, a
+// 32-byte caller-supplied key, and encodes it as a PEM block of type
+// blockType for OpenSSL-style tooling. The block carries X-Lseco-Size
+// (the storage size) and X-Lseco-Alg headers; its body uses the
+// MarshalBinary layout, and plaintext never leaves the C buffer.
+//
+// Marshaling counts as one retrieval for WithMaxRetrievals.
+func (s *SecureStorage) MarshalPEM(blockType string, key []byte) ([]byte, error) {
+	if blockType == "" {
+		return nil, fmt.Errorf("PEM block type cannot be empty")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+	sealed, err := s.sealLocked(aead, nil)
+	if err != nil {
+		return nil, err
+	}
+	block := &pem.Block{
+		Type: blockType,
+		Headers: map[string]string{
+			pemSizeHeader: strconv.Itoa(s.size),
+			pemAlgHeader:  envelopeAlgorithm,
+		},
+		Bytes: sealed,
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return pem.EncodeToMemory(block), nil
+}
+
+// UnmarshalPEM decrypts the first PEM block in pemData, as produced by
+// MarshalPEM under key, directly into a new C buffer of the encoded size,
+// which replaces the current one. The block type is not checked.
+func (s *SecureStorage) UnmarshalPEM(pemData, key []byte) error {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return fmt.Errorf("no PEM block found")
+	}
+	if alg := block.Headers[pemAlgHeader]; alg != envelopeAlgorithm {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	size, err := strconv.Atoi(block.Headers[pemSizeHeader])
+	if err != nil || len(block.Bytes) < serializationHeaderSize ||
+		uint32(size) != binary.BigEndian.Uint32(block.Bytes[1:5]) {
+		return fmt.Errorf("malformed %s header", pemSizeHeader)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.openLocked(aead, block.Bytes, nil); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
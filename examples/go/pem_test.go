@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPEMRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{3}, serializationKeySize)
+	data := []byte("signing key")
+	s := newTestStorage(t, 64)
+	mustStore(t, s, data)
+
+	block, err := s.MarshalPEM("LSECO SECRET", key)
+	if err != nil {
+		t.Fatalf("MarshalPEM failed: %v", err)
+	}
+	if !bytes.HasPrefix(block, []byte("-----BEGIN LSECO SECRET-----\n")) {
+		t.Fatalf("MarshalPEM output is not an LSECO SECRET block:\n%s", block)
+	}
+	if bytes.Contains(block, data) {
+		t.Fatal("MarshalPEM output contains the plaintext")
+	}
+
+	decoded := newTestStorage(t, 1)
+	if err := decoded.UnmarshalPEM(block, key); err != nil {
+		t.Fatalf("UnmarshalPEM failed: %v", err)
+	}
+	if decoded.Size() != 64 {
+		t.Fatalf("Size() after UnmarshalPEM = %d, want 64", decoded.Size())
+	}
+	requireContent(t, decoded, data)
+
+	wrong := newTestStorage(t, 1)
+	if err := wrong.UnmarshalPEM(block, bytes.Repeat([]byte{4}, serializationKeySize)); err == nil {
+		t.Fatal("UnmarshalPEM under the wrong key succeeded")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	encasn1 "encoding/asn1"
+	"fmt"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// NewSecureStorageFromPKCS8 loads a PKCS #8 PrivateKeyInfo DER blob, the
+// usual form of a TLS private key, into a new storage of exactly
+// len(der) bytes created with opts. Only the outer structure is checked:
+// the version, the algorithm identifier, the private key octet string,
+// and the optional attributes and public key. The key itself is never
+// parsed into a crypto.PrivateKey, so no copy of it reaches the Go heap.
+//
+// It returns ErrInvalidPKCS8 if der is not such a structure, including
+// when it is an EncryptedPrivateKeyInfo. Combine with WithZeroSource(true) to
+// also clear der.
+func NewSecureStorageFromPKCS8(der []byte, opts ...Option) (*SecureStorage, error) {
+	if err := checkPKCS8(der); err != nil {
+		return nil, err
+	}
+	return NewSecureStorageFromBytes(der, len(der), opts...)
+}
+
+// checkPKCS8 validates the PrivateKeyInfo structure of RFC 5958 without
+// copying any part of der.
+func checkPKCS8(der []byte) error {
+	input := cryptobyte.String(der)
+	var info, algorithm, privateKey cryptobyte.String
+	var version int64
+	var oid encasn1.ObjectIdentifier
+
+	if !input.ReadASN1(&info, asn1.SEQUENCE) || !input.Empty() {
+		return fmt.Errorf("%w: not a single DER sequence", ErrInvalidPKCS8)
+	}
+	if !info.ReadASN1Integer(&version) || (version != 0 && version != 1) {
+		return fmt.Errorf("%w: missing or unsupported version", ErrInvalidPKCS8)
+	}
+	if !info.ReadASN1(&algorithm, asn1.SEQUENCE) || !algorithm.ReadASN1ObjectIdentifier(&oid) {
+		return fmt.Errorf("%w: malformed algorithm identifier", ErrInvalidPKCS8)
+	}
+	if !info.ReadASN1(&privateKey, asn1.OCTET_STRING) || privateKey.Empty() {
+		return fmt.Errorf("%w: missing private key", ErrInvalidPKCS8)
+	}
+	if !info.SkipOptionalASN1(asn1.Tag(0).ContextSpecific().Constructed()) ||
+		!info.SkipOptionalASN1(asn1.Tag(1).ContextSpecific()) || !info.Empty() {
+		return fmt.Errorf("%w: trailing data after private key", ErrInvalidPKCS8)
+	}
+	return nil
+}
+
+// ExportPKCS8 returns the stored DER sealed with AES-256-GCM under key, a
+// 32-byte caller-supplied key, for handing a key loaded with
+// NewSecureStorageFromPKCS8 to another process. The output uses the
+// MarshalBinary layout, so Unseal restores it into a storage. As in
+// Seal, encryption reads straight from the C buffer, but the storage is
+// left intact.
+//
+// Exporting counts as one retrieval for WithMaxRetrievals.
+func (s *SecureStorage) ExportPKCS8(key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out, err := s.exportSealedLocked(aead)
+	if err != nil {
+		return nil, err
+	}
+	s.record(opRetrieve)
+	return out, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// SecureStoragePool hands out pre-allocated storages of a fixed size, so
+// hot paths avoid the mlock/munlock cost of creating and destroying a
+// storage per use. It is safe for concurrent use by multiple goroutines.
+type SecureStoragePool struct {
+	size int
+	opts []Option
+	idle chan *SecureStorage
+	done chan struct{} // closed by Close
+
+	mu     sync.Mutex
+	out    map[*SecureStorage]struct{} // storages currently handed out
+	closed bool
+}
+
+// NewSecureStoragePool allocates count storages of size bytes each,
+// created with opts.
+func NewSecureStoragePool(count, size int, opts ...Option) (*SecureStoragePool, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("pool count must be positive, got %d", count)
+	}
+
+	p := &SecureStoragePool{
+		size: size,
+		opts: opts,
+		idle: make(chan *SecureStorage, count),
+		done: make(chan struct{}),
+		out:  make(map[*SecureStorage]struct{}, count),
+	}
+	for i := 0; i < count; i++ {
+		s, err := NewSecureStorage(size, opts...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.idle <- s
+	}
+	return p, nil
+}
+
+// Get returns an idle storage, or ErrPoolExhausted if all of them are in
+// use.
+func (p *SecureStoragePool) Get() (*SecureStorage, error) {
+	select {
+	case s := <-p.idle:
+		return p.checkOut(s)
+	case <-p.done:
+		return nil, ErrPoolClosed
+	default:
+		return nil, ErrPoolExhausted
+	}
+}
+
+// GetCtx returns an idle storage, waiting for one to be Put back if all
+// of them are in use. It returns ctx.Err() if ctx is done first.
+func (p *SecureStoragePool) GetCtx(ctx context.Context) (*SecureStorage, error) {
+	select {
+	case s := <-p.idle:
+		return p.checkOut(s)
+	case <-p.done:
+		return nil, ErrPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *SecureStoragePool) checkOut(s *SecureStorage) (*SecureStorage, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		s.Destroy()
+		return nil, ErrPoolClosed
+	}
+	p.out[s] = struct{}{}
+	return s, nil
+}
+
+// Put wipes s and returns it to the pool. Its retrieval count and TTL
+// are reset as well. s must have been obtained from p and must not be
+// used afterwards; storages that are not currently checked out of p are
+// ignored. After Close, Put destroys s instead.
+//
+// If s cannot be wiped, for example because the caller destroyed it, it
+// is replaced by a fresh allocation.
+func (p *SecureStoragePool) Put(s *SecureStorage) {
+	p.mu.Lock()
+	if _, ok := p.out[s]; !ok {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.out, s)
+	closed := p.closed
+	p.mu.Unlock()
+
+	if closed {
+		s.Destroy()
+		return
+	}
+
+	if err := s.Wipe(); err != nil {
+		s.Destroy()
+		fresh, err := NewSecureStorage(p.size, p.opts...)
+		if err != nil {
+			warnLeak(s.cfg.logger, "lseco: failed to replace pooled SecureStorage", p.size)
+			return
+		}
+		s = fresh
+	}
+
+	s.mu.Lock()
+	s.retrievals = 0
+	s.mu.Unlock()
+
+	p.idle <- s
+}
+
+// Close destroys all idle storages. Storages still checked out are
+// destroyed when they are Put back; Get and GetCtx return ErrPoolClosed
+// from then on.
+func (p *SecureStoragePool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.done)
+	}
+	p.mu.Unlock()
+
+	for {
+		select {
+		case s := <-p.idle:
+			s.Destroy()
+		default:
+			return
+		}
+	}
+}
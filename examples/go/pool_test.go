@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPoolPutWipes(t *testing.T) {
+	p, err := NewSecureStoragePool(1, 16)
+	if err != nil {
+		t.Fatalf("NewSecureStoragePool failed: %v", err)
+	}
+	t.Cleanup(p.Close)
+
+	s, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	mustStore(t, s, []byte("session"))
+	if _, err := p.Get(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("Get from an empty pool = %v, want ErrPoolExhausted", err)
+	}
+
+	p.Put(s)
+	again, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get after Put failed: %v", err)
+	}
+	defer p.Put(again)
+	if again != s {
+		t.Fatal("Get did not hand out the storage that was put back")
+	}
+	got, err := again.Retrieve(7)
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if !bytes.Equal(got, make([]byte, 7)) {
+		t.Fatalf("pooled storage not wiped: %q", got)
+	}
+}
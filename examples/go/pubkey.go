@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+)
+
+// ExportPublicKey parses the stored private key and returns its public
+// half, e.g. for a certificate request or a key exchange. The content may
+// be DER in PKCS #8, PKCS #1 (RSA), or SEC 1 (EC) form, or a raw
+// big-endian ECDSA scalar of 32, 48, or 66 bytes, taken as a P-256,
+// P-384, or P-521 key. The result is an *rsa.PublicKey,
+// *ecdsa.PublicKey, or ed25519.PublicKey.
+//
+// The DER is parsed straight from the locked C buffer. The parsed private
+// key exists briefly on the Go heap, as crypto/x509 requires, and its
+// private values are zeroed before returning; only the public component
+// is returned. Exporting counts as one retrieval.
+func (s *SecureStorage) ExportPublicKey() (crypto.PublicKey, error) {
+	if err := checkRaw(s); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.length == 0 {
+		return nil, fmt.Errorf("no data stored")
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	var pub crypto.PublicKey
+	err := s.withViewLocked(func(view []byte) error {
+		priv, err := parsePrivateKey(view[:s.length:s.length])
+		if err != nil {
+			return err
+		}
+		defer zeroPrivateKey(priv)
+
+		pub = priv.Public()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return pub, nil
+}
+
+// parsePrivateKey parses der in any of the forms accepted by
+// ExportPublicKey.
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			zeroPrivateKey(key)
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := rawECDSAKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("content is not a PKCS #8, PKCS #1, SEC 1, or raw EC private key")
+}
+
+// rawECDSAKey builds an ECDSA key from a raw scalar, choosing the curve
+// by its length.
+func rawECDSAKey(scalar []byte) (*ecdsa.PrivateKey, error) {
+	var curve elliptic.Curve
+	var kex ecdh.Curve
+	switch len(scalar) {
+	case 32:
+		curve, kex = elliptic.P256(), ecdh.P256()
+	case 48:
+		curve, kex = elliptic.P384(), ecdh.P384()
+	case 66:
+		curve, kex = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("no curve for a %d-byte scalar", len(scalar))
+	}
+
+	// crypto/ecdh validates the scalar and derives the point.
+	priv, err := kex.NewPrivateKey(scalar)
+	if err != nil {
+		return nil, err
+	}
+	point := priv.PublicKey().Bytes() // 0x04 || X || Y
+	n := (len(point) - 1) / 2
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(point[1 : 1+n]),
+			Y:     new(big.Int).SetBytes(point[1+n:]),
+		},
+		D: new(big.Int).SetBytes(scalar),
+	}, nil
+}
+
+// zeroPrivateKey overwrites the private values of a parsed key. It is
+// best effort: copies made inside crypto packages are out of reach.
+func zeroPrivateKey(key any) {
+	zeroInt := func(n *big.Int) {
+		if n != nil {
+			clear(n.Bits())
+		}
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		zeroInt(k.D)
+		for _, p := range k.Primes {
+			zeroInt(p)
+		}
+		zeroInt(k.Precomputed.Dp)
+		zeroInt(k.Precomputed.Dq)
+		zeroInt(k.Precomputed.Qinv)
+	case *ecdsa.PrivateKey:
+		zeroInt(k.D)
+	case ed25519.PrivateKey:
+		SecureZero(k)
+	case *ecdh.PrivateKey:
+		// Its scalar is unexported and cannot be reached.
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import "io"
+
+// FillRandom fills the whole storage with Size() bytes read from src,
+// typically crypto/rand.Reader, to generate a key directly in locked
+// memory. Like NewSecureStorageFromReader it copies through a locked
+// staging buffer, so the random bytes never sit on the Go heap.
+//
+// If src ends early it returns io.ErrUnexpectedEOF. On any error, the
+// bytes already read are zeroed and the storage is left empty.
+func (s *SecureStorage) FillRandom(src io.Reader) error {
+	if err := checkRaw(s); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, err := s.fillFromLocked(src)
+	if err == nil && n < s.size {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return s.discardPartialLocked(n, err)
+	}
+
+	s.length = s.size
+	s.readOff = 0
+	s.writeOff = s.size
+	s.clearExpiryLocked()
+	if err := s.retagLocked(); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
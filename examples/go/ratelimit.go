@@ -0,0 +1,34 @@
+package main
+
+import "golang.org/x/time/rate"
+
+// WithRetrievalRateLimit allows at most rps retrievals per second, with
+// bursts of one, to slow down code that tries to exfiltrate the secret by
+// reading it in a tight loop. A retrieval over the limit fails with
+// ErrRateLimitExceeded instead of waiting. Every operation that counts
+// toward WithMaxRetrievals is limited, not just Retrieve. rps <= 0 means
+// unlimited.
+func WithRetrievalRateLimit(rps float64) Option {
+	return func(c *storageConfig) {
+		c.retrievalRate = rps
+	}
+}
+
+// SetRetrievalRateLimit changes the limit set by WithRetrievalRateLimit,
+// starting with a full bucket. rps <= 0 removes the limit.
+func (s *SecureStorage) SetRetrievalRateLimit(rps float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cfg.retrievalRate = rps
+	s.limiter = newRetrievalLimiter(rps)
+}
+
+// newRetrievalLimiter returns the token bucket for rps, or nil if
+// retrievals are unlimited.
+func newRetrievalLimiter(rps float64) *rate.Limiter {
+	if rps <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(rps), 1)
+}
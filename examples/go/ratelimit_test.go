@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaxRetrievals(t *testing.T) {
+	s := newTestStorage(t, 16, WithMaxRetrievals(2))
+	mustStore(t, s, []byte("otp"))
+
+	for i := range 2 {
+		if _, err := s.Retrieve(3); err != nil {
+			t.Fatalf("Retrieve %d failed: %v", i+1, err)
+		}
+	}
+	if s.Used() != 0 {
+		t.Fatalf("Used() after the last retrieval = %d, want 0", s.Used())
+	}
+	if _, err := s.Retrieve(3); !errors.Is(err, ErrRetrievalLimitExceeded) {
+		t.Fatalf("Retrieve past the limit = %v, want ErrRetrievalLimitExceeded", err)
+	}
+	if got := s.RetrievalsRemaining(); got != 0 {
+		t.Fatalf("RetrievalsRemaining() = %d, want 0", got)
+	}
+}
+
+func TestRetrievalLimitCoversEveryRead(t *testing.T) {
+	key := make([]byte, serializationKeySize)
+	tests := []struct {
+		name string
+		op   func(s *SecureStorage) error
+	}{
+		{"Seal", func(s *SecureStorage) error {
+			_, err := s.Seal(key)
+			return err
+		}},
+		{"AtomicSwap", func(s *SecureStorage) error {
+			old, err := s.AtomicSwap([]byte("next"))
+			if err == nil {
+				old.Destroy()
+			}
+			return err
+		}},
+		{"Validate", func(s *SecureStorage) error {
+			return s.Validate(func([]byte) error { return nil })
+		}},
+		{"DrainTo", func(s *SecureStorage) error {
+			_, err := s.DrainTo(make([]byte, 8))
+			return err
+		}},
+		{"Compare", func(s *SecureStorage) error {
+			other, err := NewSecureStorageFromBytes([]byte("secret"), 16)
+			if err != nil {
+				return err
+			}
+			defer other.Destroy()
+			_, err = s.Compare(other)
+			return err
+		}},
+		{"SecureCompare", func(s *SecureStorage) error {
+			_, err := SecureCompare(s, []byte("secret"))
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStorage(t, 16, WithMaxRetrievals(1))
+			mustStore(t, s, []byte("secret"))
+			if _, err := s.Retrieve(6); err != nil {
+				t.Fatalf("Retrieve failed: %v", err)
+			}
+
+			mustStore(t, s, []byte("secret"))
+			if err := tt.op(s); !errors.Is(err, ErrRetrievalLimitExceeded) {
+				t.Fatalf("%s past the limit = %v, want ErrRetrievalLimitExceeded", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestSealUnsealKeepsLimits(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, serializationKeySize)
+	s := newTestStorage(t, 16, WithMaxRetrievals(2))
+	mustStore(t, s, []byte("secret"))
+
+	sealed, err := s.Seal(key)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if err := s.Unseal(key, sealed); err != nil {
+		t.Fatalf("Unseal failed: %v", err)
+	}
+	if got := s.RetrievalsRemaining(); got != 1 {
+		t.Fatalf("RetrievalsRemaining() after Seal and Unseal = %d, want 1", got)
+	}
+}
+
+func TestSealUnsealKeepsTTL(t *testing.T) {
+	key := bytes.Repeat([]byte{2}, serializationKeySize)
+	s := newTestStorage(t, 16)
+	if err := s.StoreWithTTL([]byte("secret"), 20*time.Millisecond); err != nil {
+		t.Fatalf("StoreWithTTL failed: %v", err)
+	}
+
+	sealed, err := s.Seal(key)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if err := s.Unseal(key, sealed); err != nil {
+		t.Fatalf("Unseal failed: %v", err)
+	}
+	if _, err := s.Retrieve(6); !errors.Is(err, ErrExpired) {
+		t.Fatalf("Retrieve after the TTL = %v, want ErrExpired", err)
+	}
+}
+
+func TestRetrievalRateLimit(t *testing.T) {
+	s := newTestStorage(t, 16, WithRetrievalRateLimit(0.001))
+	mustStore(t, s, []byte("secret"))
+
+	if _, err := s.Retrieve(6); err != nil {
+		t.Fatalf("first Retrieve failed: %v", err)
+	}
+	if _, err := s.Retrieve(6); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Fatalf("second Retrieve = %v, want ErrRateLimitExceeded", err)
+	}
+
+	s.SetRetrievalRateLimit(0)
+	if _, err := s.Retrieve(6); err != nil {
+		t.Fatalf("Retrieve without a limit failed: %v", err)
+	}
+}
+
+func TestCompareCountsRetrieval(t *testing.T) {
+	s := newTestStorage(t, 16, WithMaxRetrievals(2))
+	mustStore(t, s, []byte("secret"))
+	other := newTestStorage(t, 16, WithMaxRetrievals(1))
+	mustStore(t, other, []byte("secret"))
+
+	equal, err := s.Compare(other)
+	if err != nil {
+		t.Fatalf("Compare failed: %v", err)
+	}
+	if !equal {
+		t.Fatalf("Compare() = false, want true")
+	}
+	if got := s.RetrievalsRemaining(); got != 1 {
+		t.Fatalf("RetrievalsRemaining() after Compare = %d, want 1", got)
+	}
+	if _, err := s.Compare(other); !errors.Is(err, ErrRetrievalLimitExceeded) {
+		t.Fatalf("Compare with an exhausted storage = %v, want ErrRetrievalLimitExceeded", err)
+	}
+}
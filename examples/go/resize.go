@@ -0,0 +1,117 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import "fmt"
+
+// Resize moves the content into a new allocation of newSize bytes. The
+// copy happens inside the C layer, which zeroes and frees the old region
+// before returning, so the secret is never duplicated on the Go heap and
+// no second live copy remains.
+//
+// It returns ErrDataTruncation if newSize is smaller than Used() or would
+// cut off a named slot, and ErrSizeExceedsMax if newSize is above the
+// WithMaxSize cap.
+func (s *SecureStorage) Resize(newSize int) error {
+	if newSize <= 0 {
+		return fmt.Errorf("invalid size %d", newSize)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.cfg.checkSize(newSize); err != nil {
+		return err
+	}
+	return s.resizeLocked(newSize)
+}
+
+// Truncate shrinks the allocation to n bytes, for a secret known never
+// to grow past n, so less memory stays mlock-ed. It is the counterpart of
+// Shrink: Size() becomes n and Used() is unchanged. As in Resize, the
+// content moves into a new locked region and the old one, including bytes
+// [n:Size()], is zeroed and freed by the C layer.
+//
+// It returns ErrDataTruncation if Used() is greater than n, and
+// ErrInvalidLength if n is not in [1, Size()].
+func (s *SecureStorage) Truncate(n int) error {
+	if err := checkRaw(s); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n <= 0 || n > s.size {
+		return fmt.Errorf("%w: %d (size: %d)", ErrInvalidLength, n, s.size)
+	}
+	if n == s.size {
+		return nil
+	}
+	return s.resizeLocked(n)
+}
+
+func (s *SecureStorage) resizeLocked(newSize int) error {
+	if newSize < s.length || newSize < s.slots.end {
+		return fmt.Errorf("%w: size %d cannot hold %d bytes in use",
+			ErrDataTruncation, newSize, max(s.length, s.slots.end))
+	}
+
+	// Check before moving, so that corruption is not laundered by retagging
+	// the new region.
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
+
+	result := C.lseco_resize(s.handle, C.size_t(newSize+integrityTagSize))
+	if result != C.LSECO_SUCCESS {
+		return resultError("resize", result)
+	}
+
+	s.cfg.metrics.resized(s.size, newSize)
+	oldSize := s.size
+	s.size = newSize
+	if newSize > oldSize {
+		// The C layer moved the old tag along with the user bytes; zero it
+		// and the rest of the grown region so it never reads as content.
+		return s.wipeLocked(oldSize, newSize-oldSize)
+	}
+	return s.retagLocked()
+}
+
+// Shrink trims the content to its first newUsed bytes without
+// reallocating, e.g. when a PIN gets shorter. The bytes between newUsed
+// and Used() are zeroed in the C layer and Used() becomes newUsed; Size()
+// is unchanged. It returns ErrInvalidLength if newUsed is negative or
+// greater than Used().
+func (s *SecureStorage) Shrink(newUsed int) error {
+	if err := checkRaw(s); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if newUsed < 0 || newUsed > s.length {
+		return fmt.Errorf("%w: %d (used: %d)", ErrInvalidLength, newUsed, s.length)
+	}
+	if newUsed == s.length {
+		return nil
+	}
+
+	// As in Resize, corruption must not be laundered by retagging.
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
+	if err := s.wipeLocked(newUsed, s.length-newUsed); err != nil {
+		return err
+	}
+
+	s.length = newUsed
+	s.readOff = min(s.readOff, newUsed)
+	s.writeOff = min(s.writeOff, newUsed)
+	s.record(opStore)
+	return nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestResizeZeroesGrownRegion(t *testing.T) {
+	s := newTestStorage(t, 16)
+	mustStore(t, s, bytes.Repeat([]byte{0xff}, 16))
+
+	if err := s.Resize(64); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	tail := make([]byte, 48)
+	if _, err := s.ReadAt(tail, 16); err != nil {
+		t.Fatalf("ReadAt failed: %v", err)
+	}
+	if !bytes.Equal(tail, make([]byte, 48)) {
+		t.Fatalf("grown region = %x, want zeros", tail)
+	}
+	requireContent(t, s, bytes.Repeat([]byte{0xff}, 16))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Rotate replaces the content with newData under one write lock, like
+// AtomicSwap, but for key rotation: the old secret is overwritten in
+// place and zeroed rather than handed back, the rotation is reported to
+// the AuditHandler (as OnRotate if it implements RotationAuditHandler),
+// and RotationCount is incremented. It returns ErrDataTooLarge, leaving
+// the current secret intact, if len(newData) > Cap().
+func (s *SecureStorage) Rotate(newData []byte) (err error) {
+	if len(newData) == 0 {
+		return fmt.Errorf("data cannot be empty")
+	}
+
+	end := s.startSpan(context.Background(), opRotate)
+	defer func() { end(len(newData), err) }()
+
+	key, err := s.cfg.generateEnvelopeKey()
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.cfg.encoded() && len(newData) > s.size {
+		return fmt.Errorf("%w: %d > %d", ErrDataTooLarge, len(newData), s.size)
+	}
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
+
+	oldLength := s.length
+	if err := s.storeLocked(newData, key); err != nil {
+		return err
+	}
+	if oldLength > s.length {
+		if err := s.wipeLocked(s.length, oldLength-s.length); err != nil {
+			return err
+		}
+	}
+
+	s.rotations++
+	s.record(opRotate)
+	return nil
+}
+
+// RotationCount returns the number of successful Rotate calls.
+func (s *SecureStorage) RotationCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rotations
+}
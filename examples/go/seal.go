@@ -0,0 +1,63 @@
+package main
+
+// Seal encrypts the stored content with AES-256-GCM under key, a 32-byte
+// caller-supplied key, then wipes the storage like Wipe. The ciphertext
+// is an ordinary []byte that is safe to keep in swappable memory; Unseal
+// brings the content back.
+//
+// Sealing counts as one retrieval for WithMaxRetrievals and
+// WithRetrievalRateLimit and is refused once the content has expired.
+// Unlike Wipe, it keeps the StoreWithTTL deadline, so unsealing into the
+// same storage does not extend the content's lifetime.
+//
+// Encryption reads directly from the mapped C buffer, so the plaintext is
+// never copied onto the Go heap. Like MarshalBinary, only the content
+// written by Store or Write is sealed; named slots are wiped but not
+// preserved.
+func (s *SecureStorage) Seal(key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ciphertext, err := s.exportSealedLocked(aead)
+	if err != nil {
+		return nil, err
+	}
+	s.record(opRetrieve)
+
+	if err := s.wipeAllLocked(); err != nil {
+		clear(ciphertext)
+		return nil, err
+	}
+	return ciphertext, nil
+}
+
+// Unseal decrypts ciphertext produced by Seal under key directly into a
+// new C buffer of the sealed size, which replaces the current one. The
+// retrieval count and any StoreWithTTL deadline of s carry over to the
+// unsealed content; if the deadline has passed, retrievals return
+// ErrExpired and the content is wiped.
+func (s *SecureStorage) Unseal(key, ciphertext []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline := s.expiresAt
+	if err := s.openLocked(aead, ciphertext, nil); err != nil {
+		return err
+	}
+	if !deadline.IsZero() {
+		s.expiresAt = deadline
+		expiry.schedule(s, deadline, s.expiryGen)
+	}
+	s.record(opStore)
+	return nil
+}
@@ -0,0 +1,275 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Serialized layout: version (1 byte), storage size (uint32), content
+// length (uint32), content encoding (1 byte), plaintext length (uint32),
+// GCM nonce, then the sealed content. The header is authenticated as
+// additional data, so none of it can be tampered with. Version 1 had no
+// encoding or plaintext length and is still accepted for raw content.
+const (
+	serializationVersion    = 2
+	serializationHeaderSize = 1 + 4 + 4 + 1 + 4
+	serializationKeySize    = 32 // AES-256
+
+	serializationV1HeaderSize = 1 + 4 + 4
+)
+
+// envelopeEncoding is the content encoding byte of envelope encrypted
+// content; compressed content uses its CompressionAlgorithm, and raw
+// content 0.
+const envelopeEncoding = 0xff
+
+// contentEncoding returns the encoding byte written to the serialized
+// header for content stored under c.
+func (c *storageConfig) contentEncoding() byte {
+	if c.keyWrapper != nil {
+		return envelopeEncoding
+	}
+	return byte(c.compression)
+}
+
+// envelopeAlgorithm identifies the cipher in the metadata written by
+// MarshalJSON and MarshalPEM.
+const envelopeAlgorithm = "AES-256-GCM"
+
+// MarshalBinary implements encoding.BinaryMarshaler. The stored content
+// is sealed with AES-256-GCM under the key set by WithSerializationKey,
+// or LSECO_SERIALIZATION_KEY if none was set; plaintext never leaves the
+// C buffer. Only the content written by Store or Write is serialized, not
+// named slots or stream cursors.
+//
+// Marshaling counts as one retrieval for WithMaxRetrievals.
+func (s *SecureStorage) MarshalBinary() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out, err := s.marshalLocked()
+	if err != nil {
+		return nil, err
+	}
+	s.record(opRetrieve)
+	return out, nil
+}
+
+func (s *SecureStorage) marshalLocked() ([]byte, error) {
+	aead, err := newSerializationAEAD(s.cfg.serializationKey)
+	if err != nil {
+		return nil, err
+	}
+	return s.exportSealedLocked(aead)
+}
+
+// exportSealedLocked seals the content under aead as one retrieval.
+func (s *SecureStorage) exportSealedLocked(aead cipher.AEAD) ([]byte, error) {
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	out, err := s.sealLocked(aead, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.countRetrievalLocked()
+	return out, nil
+}
+
+// sealLocked encrypts the stored content under aead, reading it straight
+// from the mapped C buffer. Encoded content is sealed as is, together with
+// its encoding and plaintext length. additionalData is authenticated after
+// the header but not included in the output.
+func (s *SecureStorage) sealLocked(aead cipher.AEAD, additionalData []byte) ([]byte, error) {
+	header := make([]byte, serializationHeaderSize, serializationHeaderSize+aead.NonceSize()+s.length+aead.Overhead())
+	header[0] = serializationVersion
+	binary.BigEndian.PutUint32(header[1:5], uint32(s.size))
+	binary.BigEndian.PutUint32(header[5:9], uint32(s.length))
+	header[9] = s.cfg.contentEncoding()
+	if s.cfg.encoded() {
+		binary.BigEndian.PutUint32(header[10:14], uint32(s.plainLength))
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	aad := append(header[:serializationHeaderSize:serializationHeaderSize], additionalData...)
+	out := append(header, nonce...)
+
+	err := s.withViewLocked(func(view []byte) error {
+		out = aead.Seal(out, nonce, view[:s.length], aad)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It decrypts data
+// produced by MarshalBinary directly into a newly created C buffer of the
+// serialized size and replaces the current one, which is destroyed.
+//
+// The key comes from the receiver's WithSerializationKey option or, if it
+// has none, from the LSECO_SERIALIZATION_KEY environment variable. The
+// latter lets decoders fill in zero-value storages, for example:
+//
+//	var cfg struct{ Token *SecureStorage }
+//	err := json.Unmarshal(data, &cfg)
+func (s *SecureStorage) UnmarshalBinary(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.unmarshalLocked(data); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
+
+func (s *SecureStorage) unmarshalLocked(data []byte) error {
+	aead, err := newSerializationAEAD(s.cfg.serializationKey)
+	if err != nil {
+		return err
+	}
+	return s.openLocked(aead, data, nil)
+}
+
+// openLocked decrypts data produced by sealLocked with the same
+// additionalData into a new C buffer, which replaces the current one. The
+// content must have been sealed from a storage with the same encoding as
+// s.
+func (s *SecureStorage) openLocked(aead cipher.AEAD, data, additionalData []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("serialized data too short")
+	}
+	headerSize := serializationHeaderSize
+	switch data[0] {
+	case serializationVersion:
+	case 1:
+		headerSize = serializationV1HeaderSize
+	default:
+		return fmt.Errorf("unsupported serialization version %d", data[0])
+	}
+	if len(data) < headerSize+aead.NonceSize()+aead.Overhead() {
+		return fmt.Errorf("serialized data too short")
+	}
+	header := data[:headerSize:headerSize]
+	size := int(binary.BigEndian.Uint32(header[1:5]))
+	length := int(binary.BigEndian.Uint32(header[5:9]))
+	var encoding byte
+	var plainLength int
+	if headerSize > serializationV1HeaderSize {
+		encoding = header[9]
+		plainLength = int(binary.BigEndian.Uint32(header[10:14]))
+	}
+	nonce := data[headerSize : headerSize+aead.NonceSize()]
+	sealed := data[headerSize+aead.NonceSize():]
+	aad := append(header, additionalData...)
+	if size == 0 || length > size || len(sealed) != length+aead.Overhead() {
+		return fmt.Errorf("malformed serialized data")
+	}
+	if encoding != s.cfg.contentEncoding() {
+		return fmt.Errorf("%w: serialized encoding %d does not match storage encoding %d",
+			ErrEncodedContent, encoding, s.cfg.contentEncoding())
+	}
+	if err := s.cfg.checkSize(size); err != nil {
+		return err
+	}
+
+	key := s.integrityKey
+	if key == nil {
+		var err error
+		if key, err = newIntegrityKey(); err != nil {
+			return err
+		}
+	}
+
+	handle, err := s.cfg.createHandle(size+integrityTagSize, s.alignment)
+	if err != nil {
+		return err
+	}
+	fresh := &SecureStorage{handle: handle, size: size, integrityKey: key}
+
+	err = fresh.withViewLocked(func(view []byte) error {
+		// view has capacity for the plaintext, so Open decrypts in place
+		// instead of allocating, and zeroes it again on failure.
+		if _, err := aead.Open(view[:0], nonce, sealed, aad); err != nil {
+			return fmt.Errorf("failed to decrypt serialized data: %w", err)
+		}
+		return nil
+	})
+	if err == nil {
+		err = fresh.retagLocked()
+	}
+	if err != nil {
+		C.lseco_destroy(handle)
+		return err
+	}
+
+	if s.handle != nil {
+		C.lseco_destroy(s.handle)
+		s.cfg.metrics.resized(s.size, size)
+	} else {
+		runtime.SetFinalizer(s, finalizeStorage)
+		s.cfg.metrics.allocated(size)
+	}
+	s.handle = handle
+	s.size = size
+	s.integrityKey = key
+	s.length = length
+	s.plainLength = plainLength
+	s.readOff = 0
+	s.writeOff = length
+	s.slots = slotIndex{}
+	s.clearExpiryLocked()
+	return nil
+}
+
+// serializationKeyEnv names the environment variable holding a
+// base64-encoded fallback serialization key.
+const serializationKeyEnv = "LSECO_SERIALIZATION_KEY"
+
+// newSerializationAEAD returns the cipher for MarshalBinary and
+// UnmarshalBinary, falling back to serializationKeyEnv if key is nil.
+func newSerializationAEAD(key []byte) (cipher.AEAD, error) {
+	if key == nil {
+		encoded, ok := os.LookupEnv(serializationKeyEnv)
+		if !ok {
+			return nil, ErrNoSerializationKey
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", serializationKeyEnv, err)
+		}
+		defer SecureZero(decoded)
+		key = decoded
+	}
+	return newAEAD(key)
+}
+
+// newAEAD returns AES-256-GCM keyed with key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != serializationKeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", serializationKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
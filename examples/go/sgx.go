@@ -0,0 +1,27 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+
+// SGXAvailable reports whether the C library can place storages inside
+// an Intel SGX enclave, so callers can decide at runtime whether to pass
+// WithSGXEnclave. The library does not ship an enclave yet, so it
+// currently always returns false.
+func SGXAvailable() bool {
+	return C.lseco_sgx_available() != 0
+}
+
+// WithSGXEnclave requests that the storage live inside the SGX enclave
+// enclaveID, with the C layer routing create, store, and retrieve through
+// its ECALL interface while the SecureStorage API stays the same. Until
+// SGXAvailable reports true, creating such a storage fails with
+// ErrSGXUnavailable rather than silently falling back to ordinary locked
+// memory.
+func WithSGXEnclave(enclaveID uint64) Option {
+	return func(c *storageConfig) {
+		c.sgxEnclave = true
+		c.sgxEnclaveID = enclaveID
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// Split divides the content into n XOR shares, each a new storage of
+// Used() bytes: n-1 of them are filled from crypto/rand, and the last is
+// the content XOR-ed with all of them, so any n-1 shares reveal nothing
+// and Combine of all n recovers the secret. Every share is built inside
+// the C layer and never touches the Go heap. s is left unchanged.
+//
+// Splitting counts as one retrieval. The shares are created without s's
+// options and must each be destroyed.
+func (s *SecureStorage) Split(n int) ([]*SecureStorage, error) {
+	if n < 2 {
+		return nil, fmt.Errorf("need at least 2 shares, got %d", n)
+	}
+	if err := checkRaw(s); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.length == 0 {
+		return nil, fmt.Errorf("no data stored")
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	shares := make([]*SecureStorage, 0, n)
+	fail := func(err error) ([]*SecureStorage, error) {
+		for _, share := range shares {
+			share.Destroy()
+		}
+		return nil, err
+	}
+
+	for range n - 1 {
+		share, err := NewSecureStorage(s.length)
+		if err != nil {
+			return fail(err)
+		}
+		shares = append(shares, share)
+		if err := share.FillRandom(rand.Reader); err != nil {
+			return fail(err)
+		}
+	}
+
+	last, err := NewSecureStorage(s.length)
+	if err != nil {
+		return fail(err)
+	}
+	shares = append(shares, last)
+	result := C.lseco_copy_at(last.handle, 0, s.handle, 0, C.size_t(s.length))
+	if result != C.LSECO_SUCCESS {
+		return fail(resultError("split", result))
+	}
+	if err := last.xorSharesLocked(shares[:n-1]); err != nil {
+		return fail(err)
+	}
+	s.countRetrievalLocked()
+
+	s.record(opRetrieve)
+	return shares, nil
+}
+
+// Combine XORs shares produced by Split back together into a new storage
+// holding the original secret. All shares must have the same Used();
+// otherwise it returns ErrSizeMismatch. The shares are left unchanged,
+// and each counts one retrieval.
+func Combine(shares []*SecureStorage) (*SecureStorage, error) {
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("need at least 2 shares, got %d", len(shares))
+	}
+	if err := checkRaw(shares...); err != nil {
+		return nil, err
+	}
+
+	length := shares[0].Used()
+	if length == 0 {
+		return nil, fmt.Errorf("share is empty")
+	}
+
+	// Pre-zeroed, since the shares are XOR-ed into it.
+	combined, err := NewSecureStorage(length, WithPreZero(true))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := combined.xorSharesLocked(shares); err != nil {
+		combined.Destroy()
+		return nil, err
+	}
+
+	combined.record(opStore)
+	return combined, nil
+}
+
+// xorSharesLocked XORs each storage in shares into s, which must be
+// unshared, then marks the first length bytes as content. Each share is
+// locked in turn and counts one retrieval.
+func (s *SecureStorage) xorSharesLocked(shares []*SecureStorage) error {
+	for _, share := range shares {
+		share.mu.Lock()
+		err := share.xorIntoLocked(s)
+		share.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.length = s.size
+	s.readOff = 0
+	s.writeOff = s.size
+	return s.retagLocked()
+}
+
+// xorIntoLocked XORs the content of s into dst, whose size must equal
+// s.Used().
+func (s *SecureStorage) xorIntoLocked(dst *SecureStorage) error {
+	if s.length != dst.size {
+		return fmt.Errorf("%w: %d != %d", ErrSizeMismatch, s.length, dst.size)
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return err
+	}
+
+	result := C.lseco_xor(dst.handle, s.handle, C.size_t(s.length))
+	if result != C.LSECO_SUCCESS {
+		return resultError("xor", result)
+	}
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	data := []byte("master key")
+	s := newTestStorage(t, 32)
+	mustStore(t, s, data)
+
+	shares, err := s.Split(3)
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	for _, share := range shares {
+		t.Cleanup(share.Destroy)
+		if share.Used() != len(data) {
+			t.Fatalf("share Used() = %d, want %d", share.Used(), len(data))
+		}
+	}
+
+	combined, err := Combine(shares)
+	if err != nil {
+		t.Fatalf("Combine failed: %v", err)
+	}
+	t.Cleanup(combined.Destroy)
+	requireContent(t, combined, data)
+
+	partial, err := Combine(shares[:2])
+	if err != nil {
+		t.Fatalf("Combine of two shares failed: %v", err)
+	}
+	t.Cleanup(partial.Destroy)
+	got, err := partial.RetrieveAll()
+	if err != nil {
+		t.Fatalf("RetrieveAll failed: %v", err)
+	}
+	if bytes.Equal(got, data) {
+		t.Fatal("a subset of the shares recovered the secret")
+	}
+}
+
+func TestSplitRejectsOneShare(t *testing.T) {
+	s := newTestStorage(t, 32)
+	mustStore(t, s, []byte("master key"))
+
+	if _, err := s.Split(1); err == nil {
+		t.Fatal("Split(1) succeeded")
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"fmt"
+)
+
+// Sign signs the stored content with privateKey, for example to attest a
+// device identity key with a parent key. The content is hashed by Hash
+// with the digest algorithm from opts, and only the digest is passed to
+// privateKey, so signers backed by hardware or crypto/ecdsa and
+// crypto/rsa keys all work without the content leaving locked memory.
+//
+// opts must name a digest algorithm: pure Ed25519, which signs the whole
+// message, is not supported, but Ed25519ph (ed25519.Options with
+// crypto.SHA512) is. Signing counts as one retrieval.
+func (s *SecureStorage) Sign(privateKey crypto.Signer, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := opts.HashFunc()
+	if hashFunc == 0 {
+		return nil, fmt.Errorf("sign requires a digest algorithm")
+	}
+	if !hashFunc.Available() {
+		return nil, fmt.Errorf("digest algorithm %v is not linked into the binary", hashFunc)
+	}
+
+	digest, err := s.Hash(hashFunc.New())
+	if err != nil {
+		return nil, err
+	}
+	return privateKey.Sign(rand.Reader, digest, opts)
+}
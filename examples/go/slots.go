@@ -0,0 +1,237 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"sort"
+	"unsafe"
+)
+
+// namedSlot is a region of the C buffer reserved for one named secret.
+type namedSlot struct {
+	offset   int
+	capacity int
+	length   int
+}
+
+// slotIndex maps slot names to regions of the C buffer. It only holds
+// offsets and lengths, never secret content.
+type slotIndex struct {
+	active map[string]namedSlot
+	free   []namedSlot // zeroed regions available for reuse
+	end    int         // first byte never handed out to a slot
+}
+
+// alloc reserves n bytes, reusing a freed region when one is large
+// enough. It returns false if the buffer has no room left.
+func (idx *slotIndex) alloc(n, size int) (namedSlot, bool) {
+	for i, f := range idx.free {
+		if f.capacity < n {
+			continue
+		}
+		idx.free = append(idx.free[:i], idx.free[i+1:]...)
+		if rest := f.capacity - n; rest > 0 {
+			idx.free = append(idx.free, namedSlot{offset: f.offset + n, capacity: rest})
+		}
+		return namedSlot{offset: f.offset, capacity: n}, true
+	}
+
+	if n > size-idx.end {
+		return namedSlot{}, false
+	}
+	slot := namedSlot{offset: idx.end, capacity: n}
+	idx.end += n
+	return slot, true
+}
+
+// release returns an already zeroed region to the index, merging it
+// with adjacent free regions.
+func (idx *slotIndex) release(slot namedSlot) {
+	idx.free = append(idx.free, namedSlot{offset: slot.offset, capacity: slot.capacity})
+	sort.Slice(idx.free, func(i, j int) bool {
+		return idx.free[i].offset < idx.free[j].offset
+	})
+
+	merged := idx.free[:1]
+	for _, f := range idx.free[1:] {
+		last := &merged[len(merged)-1]
+		if last.offset+last.capacity == f.offset {
+			last.capacity += f.capacity
+			continue
+		}
+		merged = append(merged, f)
+	}
+	idx.free = merged
+
+	if last := idx.free[len(idx.free)-1]; last.offset+last.capacity == idx.end {
+		idx.end = last.offset
+		idx.free = idx.free[:len(idx.free)-1]
+	}
+}
+
+// StoreNamed stores data in the slot called name, creating the slot if
+// needed. Slots are carved out of the same C buffer, so the sum of all
+// slot sizes is bounded by the storage size.
+//
+// Named slots share the buffer with Store and Write; a storage should be
+// used either as a single blob or as a set of named slots, not both.
+func (s *SecureStorage) StoreNamed(name string, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("data cannot be empty")
+	}
+	if err := checkRaw(s); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.storeNamedLocked(name, data); err != nil {
+		return err
+	}
+	s.record(opStore)
+	return nil
+}
+
+func (s *SecureStorage) storeNamedLocked(name string, data []byte) error {
+	old, exists := s.slots.active[name]
+	if exists && old.capacity >= len(data) {
+		if err := s.writeSlotLocked(old.offset, data); err != nil {
+			return err
+		}
+		if old.length > len(data) {
+			if err := s.wipeLocked(old.offset+len(data), old.length-len(data)); err != nil {
+				return err
+			}
+		}
+		old.length = len(data)
+		s.slots.active[name] = old
+		return nil
+	}
+
+	slot, ok := s.slots.alloc(len(data), s.size)
+	if !ok {
+		return fmt.Errorf("no space for slot %q: need %d bytes", name, len(data))
+	}
+	if err := s.writeSlotLocked(slot.offset, data); err != nil {
+		s.slots.release(slot)
+		return err
+	}
+	slot.length = len(data)
+
+	if exists {
+		if err := s.wipeLocked(old.offset, old.length); err != nil {
+			return err
+		}
+		s.slots.release(old)
+	}
+
+	if s.slots.active == nil {
+		s.slots.active = make(map[string]namedSlot)
+	}
+	s.slots.active[name] = slot
+	return nil
+}
+
+// RetrieveNamed retrieves the content of the slot called name.
+func (s *SecureStorage) RetrieveNamed(name string) ([]byte, error) {
+	if err := checkRaw(s); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slot, ok := s.slots.active[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrSlotNotFound, name)
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, slot.length)
+	result := C.lseco_retrieve_at(
+		s.handle,
+		C.size_t(slot.offset),
+		unsafe.Pointer(&buffer[0]),
+		C.size_t(slot.length),
+	)
+
+	if result != C.LSECO_SUCCESS {
+		return nil, resultError("retrieve", result)
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return buffer, nil
+}
+
+// DeleteNamed zeroes the slot called name and makes its space reusable.
+func (s *SecureStorage) DeleteNamed(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slot, ok := s.slots.active[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrSlotNotFound, name)
+	}
+
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
+	if err := s.wipeLocked(slot.offset, slot.length); err != nil {
+		return err
+	}
+	delete(s.slots.active, name)
+	s.slots.release(slot)
+	return nil
+}
+
+// ListNames returns the names of all active slots in sorted order.
+func (s *SecureStorage) ListNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.slots.active))
+	for name := range s.slots.active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *SecureStorage) writeSlotLocked(offset int, data []byte) error {
+	// The other slots and the content are retagged too.
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
+
+	result := C.lseco_store_at(
+		s.handle,
+		C.size_t(offset),
+		unsafe.Pointer(&data[0]),
+		C.size_t(len(data)),
+	)
+
+	if result != C.LSECO_SUCCESS {
+		return resultError("store", result)
+	}
+	return s.retagLocked()
+}
+
+// wipeLocked securely zeroes [offset, offset+length) in the C buffer.
+func (s *SecureStorage) wipeLocked(offset, length int) error {
+	if length == 0 {
+		return nil
+	}
+
+	result := C.lseco_wipe_at(s.handle, C.size_t(offset), C.size_t(length))
+	if result != C.LSECO_SUCCESS {
+		return resultError("wipe", result)
+	}
+	return s.retagLocked()
+}
@@ -0,0 +1,124 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import "fmt"
+
+// defaultMaxSnapshots is the number of snapshots retained when
+// WithMaxSnapshots is not given.
+const defaultMaxSnapshots = 8
+
+// snapshot is a checkpoint taken by Snapshot. The content lives in its
+// own locked allocation.
+type snapshot struct {
+	token   uint64
+	storage *SecureStorage
+}
+
+// Snapshot checkpoints the current content, stored and plaintext
+// lengths, cursors, and named slots into a separate locked allocation,
+// copied C-to-C, and returns a token for Rollback. Tokens increase
+// monotonically. Once more than the WithMaxSnapshots limit is held, the
+// oldest snapshot is destroyed. Snapshots have no TTL of their own and
+// are not reported to WithAuditHandler, WithMetrics, or WithOnExpiry.
+func (s *SecureStorage) Snapshot() (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied, err := s.cloneLocked(s.cfg.snapshotConfig())
+	if err != nil {
+		return 0, err
+	}
+
+	s.lastSnapshot++
+	s.snapshots = append(s.snapshots, snapshot{token: s.lastSnapshot, storage: copied})
+
+	limit := s.cfg.maxSnapshots
+	if limit <= 0 {
+		limit = defaultMaxSnapshots
+	}
+	for len(s.snapshots) > limit {
+		s.snapshots[0].storage.Destroy()
+		s.snapshots = s.snapshots[1:]
+	}
+	return s.lastSnapshot, nil
+}
+
+// Rollback restores the state checkpointed by Snapshot under token.
+// Snapshots taken after token are discarded; token itself stays valid,
+// so the same state can be restored again. It returns ErrUnknownSnapshot
+// if token was never issued, was evicted, or was discarded.
+func (s *SecureStorage) Rollback(token uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := -1
+	for j, snap := range s.snapshots {
+		if snap.token == token {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownSnapshot, token)
+	}
+	snap := s.snapshots[i].storage
+
+	snap.mu.Lock()
+	defer snap.mu.Unlock()
+
+	if err := snap.verifyLocked(); err != nil {
+		return err
+	}
+	if snap.size != s.size {
+		result := C.lseco_resize(s.handle, C.size_t(snap.size+integrityTagSize))
+		if result != C.LSECO_SUCCESS {
+			return resultError("rollback", result)
+		}
+		s.cfg.metrics.resized(s.size, snap.size)
+		s.size = snap.size
+	}
+
+	result := C.lseco_copy_at(s.handle, 0, snap.handle, 0, C.size_t(s.size))
+	if result != C.LSECO_SUCCESS {
+		return resultError("rollback", result)
+	}
+
+	s.length = snap.length
+	s.plainLength = snap.plainLength
+	s.readOff = snap.readOff
+	s.writeOff = snap.writeOff
+	s.slots = snap.slots.clone()
+	if err := s.retagLocked(); err != nil {
+		return err
+	}
+
+	for _, later := range s.snapshots[i+1:] {
+		later.storage.Destroy()
+	}
+	s.snapshots = s.snapshots[:i+1]
+	s.record(opStore)
+	return nil
+}
+
+// snapshotConfig returns the options for a snapshot of a storage
+// configured with c. A snapshot is internal to its storage, so it has no
+// expiry callback and reports nothing to the audit handler, metrics, or
+// tracer.
+func (c storageConfig) snapshotConfig() storageConfig {
+	c.audit = nil
+	c.onExpiry = nil
+	c.metrics = nil
+	c.spans = nil
+	return c
+}
+
+// dropSnapshotsLocked destroys every retained snapshot.
+func (s *SecureStorage) dropSnapshotsLocked() {
+	for _, snap := range s.snapshots {
+		snap.storage.Destroy()
+	}
+	s.snapshots = nil
+}
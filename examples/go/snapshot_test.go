@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRollback(t *testing.T) {
+	s := newTestStorage(t, 32)
+	mustStore(t, s, []byte("first"))
+
+	first, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	mustStore(t, s, []byte("second"))
+	second, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if second <= first {
+		t.Fatalf("tokens not increasing: %d then %d", first, second)
+	}
+	if err := s.Resize(64); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	mustStore(t, s, []byte("third"))
+
+	if err := s.Rollback(first); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	requireContent(t, s, []byte("first"))
+	if s.Size() != 32 {
+		t.Fatalf("Size() after Rollback = %d, want 32", s.Size())
+	}
+
+	// Rolling back discards later snapshots but keeps the token itself.
+	if err := s.Rollback(second); !errors.Is(err, ErrUnknownSnapshot) {
+		t.Fatalf("Rollback to discarded snapshot = %v, want ErrUnknownSnapshot", err)
+	}
+	if err := s.Rollback(first); err != nil {
+		t.Fatalf("second Rollback failed: %v", err)
+	}
+}
+
+func TestSnapshotEviction(t *testing.T) {
+	s := newTestStorage(t, 16, WithMaxSnapshots(2))
+	mustStore(t, s, []byte("v"))
+
+	var tokens []uint64
+	for range 3 {
+		token, err := s.Snapshot()
+		if err != nil {
+			t.Fatalf("Snapshot failed: %v", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := s.Rollback(tokens[0]); !errors.Is(err, ErrUnknownSnapshot) {
+		t.Fatalf("Rollback to evicted snapshot = %v, want ErrUnknownSnapshot", err)
+	}
+	if err := s.Rollback(tokens[1]); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+}
+
+func TestSnapshotCompressed(t *testing.T) {
+	data := bytes.Repeat([]byte("snapshot"), 20)
+	s := newTestStorage(t, 64, WithCompress(Zstd))
+	mustStore(t, s, data)
+
+	token, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	mustStore(t, s, []byte("short"))
+	if err := s.Rollback(token); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	if s.Used() != len(data) {
+		t.Fatalf("Used() after Rollback = %d, want %d", s.Used(), len(data))
+	}
+	requireContent(t, s, data)
+}
+
+func TestSnapshotDoesNotCountRetrieval(t *testing.T) {
+	s := newTestStorage(t, 16, WithMaxRetrievals(1))
+	mustStore(t, s, []byte("once"))
+
+	if _, err := s.Snapshot(); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if got := s.RetrievalsRemaining(); got != 1 {
+		t.Fatalf("RetrievalsRemaining() after Snapshot = %d, want 1", got)
+	}
+}
+
+// countingAudit counts destroy events and ignores the rest.
+type countingAudit struct {
+	destroys atomic.Int32
+}
+
+func (*countingAudit) OnStore(time.Time, runtime.Frame)    {}
+func (*countingAudit) OnRetrieve(time.Time, runtime.Frame) {}
+func (a *countingAudit) OnDestroy(time.Time, runtime.Frame) {
+	a.destroys.Add(1)
+}
+
+func TestSnapshotIsNotAudited(t *testing.T) {
+	audit := &countingAudit{}
+	s, err := NewSecureStorage(16, WithAuditHandler(audit))
+	if err != nil {
+		t.Fatalf("NewSecureStorage failed: %v", err)
+	}
+	mustStore(t, s, []byte("v"))
+	for range 2 {
+		if _, err := s.Snapshot(); err != nil {
+			t.Fatalf("Snapshot failed: %v", err)
+		}
+	}
+
+	s.Destroy()
+	if got := audit.destroys.Load(); got != 1 {
+		t.Fatalf("OnDestroy calls = %d, want 1", got)
+	}
+}
+
+func TestSnapshotHasNoTTL(t *testing.T) {
+	var expired atomic.Int32
+	s := newTestStorage(t, 16, WithOnExpiry(func(*SecureStorage) {
+		expired.Add(1)
+	}))
+	if err := s.StoreWithTTL([]byte("v"), 20*time.Millisecond); err != nil {
+		t.Fatalf("StoreWithTTL failed: %v", err)
+	}
+
+	before := expiry.len()
+	if _, err := s.Snapshot(); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if got := expiry.len(); got != before {
+		t.Fatalf("scheduled expiries after Snapshot = %d, want %d", got, before)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got := expired.Load(); got != 1 {
+		t.Fatalf("WithOnExpiry calls = %d, want 1", got)
+	}
+}
@@ -0,0 +1,212 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// socketDeliveryVersion is the single data byte sent alongside the file
+// descriptor by SendOverSocket; a stream socket cannot carry ancillary
+// data without at least one byte of payload.
+const socketDeliveryVersion = 1
+
+// SendOverSocket hands the stored content to another process on the same
+// host: it is copied from locked memory into a new memfd_create(2) file,
+// which is passed over conn with SCM_RIGHTS together with this process's
+// SCM_CREDENTIALS. The content never touches the Go heap, the disk, or
+// the environment. The local descriptor is closed once sent, so the
+// receiver owns the only reference and ReceiveFromSocket zeroes the file
+// after reading it.
+//
+// The memfd is mapped and mlock-ed only while it is being filled; once
+// sent, its pages are ordinary shared memory until the receiver has
+// consumed them. Sending counts as one retrieval.
+func (s *SecureStorage) SendOverSocket(conn *net.UnixConn) error {
+	if err := checkRaw(s); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.length == 0 {
+		return fmt.Errorf("no data stored")
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return err
+	}
+
+	fd, err := unix.MemfdCreate("lseco", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("memfd_create: %w", err)
+	}
+	defer unix.Close(fd)
+
+	if err := s.fillMemfdLocked(fd); err != nil {
+		return err
+	}
+	// Without a fixed size the receiver's mapping could be truncated
+	// under it; content is left writable so the receiver can zero it.
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_SEAL); err != nil {
+		return fmt.Errorf("failed to seal memfd: %w", err)
+	}
+
+	cred := unix.Ucred{Pid: int32(os.Getpid()), Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())}
+	oob := append(unix.UnixRights(fd), unix.UnixCredentials(&cred)...)
+	if _, _, err := conn.WriteMsgUnix([]byte{socketDeliveryVersion}, oob, nil); err != nil {
+		return fmt.Errorf("failed to send secret: %w", err)
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return nil
+}
+
+// fillMemfdLocked sizes fd to the content and copies it there through a
+// locked shared mapping.
+func (s *SecureStorage) fillMemfdLocked(fd int) error {
+	if err := unix.Ftruncate(fd, int64(s.length)); err != nil {
+		return fmt.Errorf("failed to size memfd: %w", err)
+	}
+	m, err := unix.Mmap(fd, 0, s.length, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return fmt.Errorf("failed to map memfd: %w", err)
+	}
+	defer unix.Munmap(m)
+	if err := unix.Mlock(m); err != nil {
+		return fmt.Errorf("failed to lock memfd: %w", err)
+	}
+
+	return s.withViewLocked(func(view []byte) error {
+		copy(m, view[:s.length])
+		return nil
+	})
+}
+
+// ReceiveFromSocket receives a secret sent by SendOverSocket into a new
+// storage of size bytes created with opts. The sender must run as the
+// same user as the receiver, or as root, according to the credentials
+// the kernel attaches to the message; anything else is rejected. The
+// received memfd is zeroed and closed before returning, whether or not
+// the call succeeds.
+func ReceiveFromSocket(conn *net.UnixConn, size int, opts ...Option) (*SecureStorage, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid size %d", size)
+	}
+	if err := enablePassCred(conn); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1)
+	oob := make([]byte, unix.CmsgSpace(4)+unix.CmsgSpace(unix.SizeofUcred))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive secret: %w", err)
+	}
+
+	fds, cred, err := parseDeliveryControl(oob[:oobn])
+	for _, fd := range fds {
+		defer unix.Close(fd)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n != 1 || buf[0] != socketDeliveryVersion {
+		return nil, fmt.Errorf("unsupported socket delivery message")
+	}
+	if len(fds) != 1 {
+		return nil, fmt.Errorf("expected 1 file descriptor, got %d", len(fds))
+	}
+	if cred == nil {
+		return nil, fmt.Errorf("sender credentials missing")
+	}
+	if uid := uint32(os.Getuid()); cred.Uid != uid && cred.Uid != 0 {
+		return nil, fmt.Errorf("secret sent by uid %d, expected %d", cred.Uid, uid)
+	}
+
+	return loadMemfd(fds[0], size, opts)
+}
+
+// enablePassCred sets SO_PASSCRED so the kernel attaches the sender's
+// verified credentials to received messages.
+func enablePassCred(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	if err := raw.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_PASSCRED, 1)
+	}); err != nil {
+		return err
+	}
+	if sockErr != nil {
+		return fmt.Errorf("failed to enable SO_PASSCRED: %w", sockErr)
+	}
+	return nil
+}
+
+// parseDeliveryControl extracts the passed descriptors and sender
+// credentials. Descriptors are returned even on error so the caller can
+// close them.
+func parseDeliveryControl(oob []byte) ([]int, *unix.Ucred, error) {
+	msgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed control message: %w", err)
+	}
+
+	var fds []int
+	var cred *unix.Ucred
+	for i := range msgs {
+		if msgs[i].Header.Level != unix.SOL_SOCKET {
+			continue
+		}
+		switch msgs[i].Header.Type {
+		case unix.SCM_RIGHTS:
+			rights, err := unix.ParseUnixRights(&msgs[i])
+			if err != nil {
+				return fds, nil, fmt.Errorf("malformed SCM_RIGHTS: %w", err)
+			}
+			fds = append(fds, rights...)
+		case unix.SCM_CREDENTIALS:
+			if cred, err = unix.ParseUnixCredentials(&msgs[i]); err != nil {
+				return fds, nil, fmt.Errorf("malformed SCM_CREDENTIALS: %w", err)
+			}
+		}
+	}
+	return fds, cred, nil
+}
+
+// loadMemfd copies the content of fd into a new storage and zeroes the
+// file.
+func loadMemfd(fd, size int, opts []Option) (*SecureStorage, error) {
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return nil, fmt.Errorf("failed to stat memfd: %w", err)
+	}
+	seals, err := unix.FcntlInt(uintptr(fd), unix.F_GET_SEALS, 0)
+	if err != nil {
+		return nil, fmt.Errorf("received descriptor is not a memfd: %w", err)
+	}
+	if seals&(unix.F_SEAL_SHRINK|unix.F_SEAL_GROW) != unix.F_SEAL_SHRINK|unix.F_SEAL_GROW {
+		return nil, fmt.Errorf("received memfd is not sealed against resizing")
+	}
+	length := int(st.Size)
+	if length <= 0 || length > size {
+		return nil, fmt.Errorf("invalid secret length %d (max: %d)", length, size)
+	}
+
+	m, err := unix.Mmap(fd, 0, length, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map memfd: %w", err)
+	}
+	defer unix.Munmap(m)
+	defer SecureZero(m)
+
+	return NewSecureStorageFromBytes(m, size, opts...)
+}
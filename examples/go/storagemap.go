@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SecureStorageMap maps typed keys to storages it owns, in place of an
+// ad-hoc map[string]*SecureStorage. Its lock protects the map itself; each
+// storage still guards its own content. It is safe for concurrent use by
+// multiple goroutines.
+type SecureStorageMap[K comparable] struct {
+	mu      sync.RWMutex
+	opts    []Option
+	entries map[K]*SecureStorage
+}
+
+// NewSecureStorageMap returns an empty map whose storages are created
+// with opts.
+func NewSecureStorageMap[K comparable](opts ...Option) *SecureStorageMap[K] {
+	return &SecureStorageMap[K]{
+		opts:    opts,
+		entries: make(map[K]*SecureStorage),
+	}
+}
+
+// Set stores data under key in a new storage of size bytes, destroying
+// the storage previously held under key, if any.
+func (m *SecureStorageMap[K]) Set(key K, data []byte, size int) error {
+	s, err := NewSecureStorageFromBytes(data, size, m.opts...)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	old := m.entries[key]
+	m.entries[key] = s
+	m.mu.Unlock()
+
+	if old != nil {
+		old.Destroy()
+	}
+	return nil
+}
+
+// Get returns the storage held under key. It remains owned by the map and
+// must not be destroyed by the caller.
+func (m *SecureStorageMap[K]) Get(key K) (*SecureStorage, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.entries[key]
+	return s, ok
+}
+
+// Delete destroys the storage held under key and drops it from the map.
+// It returns ErrKeyNotFound if key is not in the map.
+func (m *SecureStorageMap[K]) Delete(key K) error {
+	m.mu.Lock()
+	s, ok := m.entries[key]
+	delete(m.entries, key)
+	m.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrKeyNotFound, key)
+	}
+	s.Destroy()
+	return nil
+}
+
+// Range calls fn for each entry, in no particular order, until fn
+// returns false. The map is read-locked throughout, so fn must not call
+// Set or Delete.
+func (m *SecureStorageMap[K]) Range(fn func(K, *SecureStorage) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for k, s := range m.entries {
+		if !fn(k, s) {
+			return
+		}
+	}
+}
+
+// Len returns the number of entries.
+func (m *SecureStorageMap[K]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.entries)
+}
+
+// Close destroys every storage and empties the map.
+func (m *SecureStorageMap[K]) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, s := range m.entries {
+		s.Destroy()
+		delete(m.entries, k)
+	}
+}
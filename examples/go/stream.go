@@ -0,0 +1,318 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// Write appends p at the write cursor, implementing io.Writer.
+// Unlike Store, it never rewinds to the start of the storage, so
+// consecutive writes accumulate. Writing past the storage size writes
+// as much as fits and returns io.ErrShortWrite.
+func (s *SecureStorage) Write(p []byte) (int, error) {
+	if err := checkRaw(s); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	n := len(p)
+	if avail := s.size - s.writeOff; n > avail {
+		n = avail
+	}
+	if n == 0 {
+		return 0, io.ErrShortWrite
+	}
+	// The rest of the content is kept and retagged, so check it first.
+	if err := s.verifyLocked(); err != nil {
+		return 0, err
+	}
+
+	result := C.lseco_store_at(
+		s.handle,
+		C.size_t(s.writeOff),
+		unsafe.Pointer(&p[0]),
+		C.size_t(n),
+	)
+
+	if result != C.LSECO_SUCCESS {
+		return 0, resultError("write", result)
+	}
+
+	s.writeOff += n
+	if s.writeOff > s.length {
+		s.length = s.writeOff
+	}
+	if err := s.retagLocked(); err != nil {
+		return n, err
+	}
+	s.record(opStore)
+
+	if n < len(p) {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+// Read copies stored data from the read cursor directly into p,
+// implementing io.Reader. It returns io.EOF once every byte written by
+// Store or Write has been read.
+func (s *SecureStorage) Read(p []byte) (int, error) {
+	if err := checkRaw(s); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOff >= s.length {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return 0, err
+	}
+
+	n := len(p)
+	if avail := s.length - s.readOff; n > avail {
+		n = avail
+	}
+
+	result := C.lseco_retrieve_at(
+		s.handle,
+		C.size_t(s.readOff),
+		unsafe.Pointer(&p[0]),
+		C.size_t(n),
+	)
+
+	if result != C.LSECO_SUCCESS {
+		return 0, resultError("read", result)
+	}
+
+	s.readOff += n
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	return n, nil
+}
+
+// Reset rewinds both the read and write cursors to the start of the
+// storage. Stored data is left in place until it is overwritten.
+func (s *SecureStorage) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.readOff = 0
+	s.writeOff = 0
+}
+
+// Seek moves both the read and write cursors to offset, interpreted per
+// whence, implementing io.Seeker so the storage is an io.ReadWriteSeeker.
+// io.SeekCurrent is relative to the read cursor and io.SeekEnd to Used.
+// It returns ErrOutOfBounds for a position before the start or beyond
+// Size. Seeking past Used is allowed; a later Write there extends Used
+// over the gap, whose bytes are left as they are.
+func (s *SecureStorage) Seek(offset int64, whence int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var base int64
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		base = int64(s.readOff)
+	case io.SeekEnd:
+		base = int64(s.length)
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	pos := base + offset
+	if pos < 0 || pos > int64(s.size) {
+		return 0, fmt.Errorf("%w: position %d (size: %d)", ErrOutOfBounds, pos, s.size)
+	}
+
+	s.readOff = int(pos)
+	s.writeOff = int(pos)
+	return pos, nil
+}
+
+// stagingChunkSize is the size of the staging buffer used by WriteTo,
+// Hash, and NewSecureStorageFromReader.
+const stagingChunkSize = 4096
+
+// WriteTo writes the stored data from the read cursor onwards to w,
+// implementing io.WriterTo so io.Copy does not materialize the secret in
+// a heap slice. Like Read, it advances the read cursor, and the whole
+// call counts as one retrieval.
+//
+// The C layer has no file descriptor to hand to sendfile, so data goes
+// through a 4096-byte staging area. That area is a locked SecureBuffer
+// rather than a stack array: passing a stack array to w.Write would make
+// it escape to the heap anyway. It is zeroed before WriteTo returns, so w
+// must not retain the slices it is given, as io.Writer requires.
+func (s *SecureStorage) WriteTo(w io.Writer) (int64, error) {
+	if err := checkRaw(s); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOff >= s.length {
+		return 0, nil
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return 0, err
+	}
+
+	staging, err := NewSecureBuffer(stagingChunkSize)
+	if err != nil {
+		return 0, err
+	}
+	defer staging.Close()
+	chunk := staging.Bytes()
+
+	// Counted once the stream is done, since the last allowed retrieval
+	// wipes the storage.
+	defer s.countRetrievalLocked()
+	s.record(opRetrieve)
+
+	var total int64
+	for s.readOff < s.length {
+		n := min(s.length-s.readOff, len(chunk))
+
+		result := C.lseco_retrieve_at(
+			s.handle,
+			C.size_t(s.readOff),
+			unsafe.Pointer(&chunk[0]),
+			C.size_t(n),
+		)
+		if result != C.LSECO_SUCCESS {
+			return total, resultError("read", result)
+		}
+
+		written, err := w.Write(chunk[:n])
+		s.readOff += written
+		total += int64(written)
+		if err != nil {
+			return total, err
+		}
+		if written < n {
+			return total, io.ErrShortWrite
+		}
+	}
+	return total, nil
+}
+
+// WriteAt writes p at offset off, implementing io.WriterAt, so fields
+// such as a nonce stored next to a key can be updated in place. It does
+// not move the stream cursors, and extends Used if it writes past the
+// stored data. Writing past the storage size writes as much as fits and
+// returns io.ErrShortWrite.
+func (s *SecureStorage) WriteAt(p []byte, off int64) (int, error) {
+	if err := checkRaw(s); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if off < 0 || off > int64(s.size) {
+		return 0, fmt.Errorf("invalid offset %d (max: %d)", off, s.size)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	n := min(len(p), s.size-int(off))
+	if n == 0 {
+		return 0, io.ErrShortWrite
+	}
+	// As in Write, the rest of the content must not be laundered.
+	if err := s.verifyLocked(); err != nil {
+		return 0, err
+	}
+
+	result := C.lseco_store_at(
+		s.handle,
+		C.size_t(off),
+		unsafe.Pointer(&p[0]),
+		C.size_t(n),
+	)
+
+	if result != C.LSECO_SUCCESS {
+		return 0, resultError("write", result)
+	}
+
+	s.length = max(s.length, int(off)+n)
+	if err := s.retagLocked(); err != nil {
+		return n, err
+	}
+	s.record(opStore)
+
+	if n < len(p) {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+// ReadAt copies the bytes at offset off into p, implementing io.ReaderAt,
+// so a storage can back an io.SectionReader. Reads are bounded by Size,
+// not Used; a read that runs past the end returns the bytes before it and
+// io.EOF. It ignores the stream cursors, takes the write lock like every
+// read since the C layer revokes page access after each copy, and counts
+// as one retrieval.
+func (s *SecureStorage) ReadAt(p []byte, off int64) (int, error) {
+	if err := checkRaw(s); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if off < 0 {
+		return 0, fmt.Errorf("invalid offset %d", off)
+	}
+	if off >= int64(s.size) {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return 0, err
+	}
+
+	n := min(len(p), s.size-int(off))
+	result := C.lseco_retrieve_at(
+		s.handle,
+		C.size_t(off),
+		unsafe.Pointer(&p[0]),
+		C.size_t(n),
+	)
+
+	if result != C.LSECO_SUCCESS {
+		return 0, resultError("read", result)
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
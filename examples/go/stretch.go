@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// StretchAlgorithm selects the password hashing function used by
+// NewSecureStorageFromPassword.
+type StretchAlgorithm int
+
+const (
+	// StretchPBKDF2 is PBKDF2-HMAC-SHA-256; its params are PBKDF2Params.
+	StretchPBKDF2 StretchAlgorithm = iota
+	// StretchArgon2id is Argon2id; its params are Argon2idParams.
+	StretchArgon2id
+)
+
+func (a StretchAlgorithm) String() string {
+	switch a {
+	case StretchPBKDF2:
+		return "PBKDF2-SHA-256"
+	case StretchArgon2id:
+		return "Argon2id"
+	default:
+		return fmt.Sprintf("StretchAlgorithm(%d)", int(a))
+	}
+}
+
+// PBKDF2Params configures StretchPBKDF2.
+type PBKDF2Params struct {
+	Iterations int
+}
+
+// Argon2idParams configures StretchArgon2id. Memory is in KiB.
+type Argon2idParams struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// Default stretching parameters, used when params is nil: the OWASP
+// iteration count for PBKDF2-SHA-256 and the second recommended option of
+// RFC 9106 for Argon2id.
+var (
+	DefaultPBKDF2Params   = PBKDF2Params{Iterations: 600_000}
+	DefaultArgon2idParams = Argon2idParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+)
+
+// NewSecureStorageFromPassword stretches password with algorithm into an
+// outputSize-byte key and returns it in a new storage of that size.
+// params must be a PBKDF2Params or Argon2idParams matching algorithm, or
+// nil for the defaults. The salt is used as given; generating a random
+// one per password is the caller's job.
+//
+// password is zeroed before the call returns, whether or not it succeeds.
+// Both hash implementations return the key in a Go slice, which is
+// zeroed as soon as it has been copied into locked memory.
+func NewSecureStorageFromPassword(password, salt []byte, algorithm StretchAlgorithm, params any, outputSize int, opts ...Option) (*SecureStorage, error) {
+	defer SecureZero(password)
+
+	if outputSize <= 0 {
+		return nil, fmt.Errorf("invalid output size %d", outputSize)
+	}
+
+	key, err := stretchPassword(password, salt, algorithm, params, outputSize)
+	if err != nil {
+		return nil, err
+	}
+	defer SecureZero(key)
+
+	return NewSecureStorageFromBytes(key, outputSize, opts...)
+}
+
+func stretchPassword(password, salt []byte, algorithm StretchAlgorithm, params any, outputSize int) ([]byte, error) {
+	switch algorithm {
+	case StretchPBKDF2:
+		p := DefaultPBKDF2Params
+		if params != nil {
+			var ok bool
+			if p, ok = params.(PBKDF2Params); !ok {
+				return nil, fmt.Errorf("%v requires PBKDF2Params, got %T", algorithm, params)
+			}
+		}
+		if p.Iterations <= 0 {
+			return nil, fmt.Errorf("invalid PBKDF2 iteration count %d", p.Iterations)
+		}
+		// x/crypto/pbkdf2 takes the password as a slice, so unlike
+		// crypto/pbkdf2 no unzeroable string copy is made.
+		return pbkdf2.Key(password, salt, p.Iterations, outputSize, sha256.New), nil
+
+	case StretchArgon2id:
+		p := DefaultArgon2idParams
+		if params != nil {
+			var ok bool
+			if p, ok = params.(Argon2idParams); !ok {
+				return nil, fmt.Errorf("%v requires Argon2idParams, got %T", algorithm, params)
+			}
+		}
+		if p.Time == 0 || p.Threads == 0 || p.Memory < 8*uint32(p.Threads) {
+			return nil, fmt.Errorf("invalid Argon2id parameters %+v", p)
+		}
+		return argon2.IDKey(password, salt, p.Time, p.Memory, p.Threads, uint32(outputSize)), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported stretch algorithm %v", algorithm)
+	}
+}
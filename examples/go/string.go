@@ -0,0 +1,122 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"crypto/subtle"
+	"fmt"
+	"unsafe"
+)
+
+// SecureString holds a string as a NUL-terminated C string in locked,
+// access-protected memory. Formatting it with the fmt package prints
+// [SECURE STRING len=N] instead of the value, so it is safe to log by
+// accident. It is safe for concurrent use by multiple goroutines.
+type SecureString struct {
+	storage *SecureStorage
+	length  int
+}
+
+// NewSecureString copies value into locked memory. value itself is an
+// immutable Go string and cannot be zeroed, so prefer building the
+// SecureString as close as possible to where the secret is read.
+func NewSecureString(value string) (*SecureString, error) {
+	storage, err := NewSecureStorage(len(value) + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	storage.mu.Lock()
+	err = storeCString(storage, value)
+	storage.mu.Unlock()
+	if err != nil {
+		storage.Destroy()
+		return nil, err
+	}
+
+	return &SecureString{storage: storage, length: len(value)}, nil
+}
+
+func storeCString(storage *SecureStorage, value string) error {
+	if len(value) > 0 {
+		result := C.lseco_store_at(
+			storage.handle,
+			0,
+			unsafe.Pointer(unsafe.StringData(value)),
+			C.size_t(len(value)),
+		)
+		if result != C.LSECO_SUCCESS {
+			return resultError("store", result)
+		}
+	}
+
+	var terminator [1]byte
+	result := C.lseco_store_at(
+		storage.handle,
+		C.size_t(len(value)),
+		unsafe.Pointer(&terminator[0]),
+		1,
+	)
+	if result != C.LSECO_SUCCESS {
+		return resultError("store", result)
+	}
+	return storage.retagLocked()
+}
+
+// Len returns the length of the string in bytes, without the terminator.
+func (s *SecureString) Len() int {
+	return s.length
+}
+
+// String returns a copy of the value, or "" once the SecureString is
+// destroyed.
+//
+// WARNING: the returned string is an ordinary Go string on the heap. It
+// cannot be zeroed and lives until the garbage collector reuses its
+// memory. Prefer Equal, or keep the copy's lifetime as short as possible.
+func (s *SecureString) String() string {
+	s.storage.mu.Lock()
+	defer s.storage.mu.Unlock()
+
+	var value string
+	err := s.storage.withViewLocked(func(view []byte) error {
+		value = string(view[:s.length])
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// Equal reports whether the value equals other, in time that depends
+// only on the lengths of the two strings, never on their content.
+func (s *SecureString) Equal(other string) bool {
+	s.storage.mu.Lock()
+	defer s.storage.mu.Unlock()
+
+	equal := false
+	err := s.storage.withViewLocked(func(view []byte) error {
+		otherBytes := unsafe.Slice(unsafe.StringData(other), len(other))
+		equal = subtle.ConstantTimeCompare(view[:s.length], otherBytes) == 1
+		return nil
+	})
+	return err == nil && equal
+}
+
+// Format implements fmt.Formatter, redacting the value for every verb.
+func (s *SecureString) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, "[SECURE STRING len=%d]", s.length)
+}
+
+// GoString implements fmt.GoStringer with the same redaction as Format.
+func (s *SecureString) GoString() string {
+	return fmt.Sprintf("[SECURE STRING len=%d]", s.length)
+}
+
+// Destroy zeroes and frees the locked memory.
+func (s *SecureString) Destroy() {
+	s.storage.Destroy()
+}
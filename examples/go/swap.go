@@ -0,0 +1,82 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import "fmt"
+
+// AtomicSwap replaces the content with newData and returns the previous
+// content as a new SecureStorage of the same size, all under one write
+// lock, so concurrent readers observe either the old or the new value and
+// never a gap. The old content is copied C-to-C and never touches the Go
+// heap. Like a Clone, the returned storage has the options, retrieval
+// count, and TTL deadline of s; it must be destroyed by the caller.
+//
+// Returning the old content counts as one retrieval, so AtomicSwap fails
+// like Retrieve once the content has expired or the retrieval limits are
+// reached.
+func (s *SecureStorage) AtomicSwap(newData []byte) (*SecureStorage, error) {
+	if len(newData) == 0 {
+		return nil, fmt.Errorf("data cannot be empty")
+	}
+
+	key, err := s.cfg.generateEnvelopeKey()
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.cfg.encoded() && len(newData) > s.size {
+		return nil, fmt.Errorf("data size %d exceeds storage size %d", len(newData), s.size)
+	}
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+
+	old, err := newSecureStorage(s.size, s.alignment, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if s.length > 0 {
+		result := C.lseco_copy_at(old.handle, 0, s.handle, 0, C.size_t(s.length))
+		if result != C.LSECO_SUCCESS {
+			old.Destroy()
+			return nil, resultError("swap", result)
+		}
+		if err := old.retagLocked(); err != nil {
+			old.Destroy()
+			return nil, err
+		}
+	}
+	old.length = s.length
+	old.plainLength = s.plainLength
+	old.writeOff = s.length
+	old.retrievals = s.retrievals
+	old.limiter = s.limiter
+	if !s.expiresAt.IsZero() {
+		old.expiresAt = s.expiresAt
+		expiry.schedule(old, old.expiresAt, old.expiryGen)
+	}
+
+	if err := s.storeLocked(newData, key); err != nil {
+		old.Destroy()
+		return nil, err
+	}
+	// Store leaves bytes past the new length in place; clear what is left
+	// of the old credential.
+	if old.length > s.length {
+		if err := s.wipeLocked(s.length, old.length-s.length); err != nil {
+			old.Destroy()
+			return nil, err
+		}
+	}
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	s.record(opStore)
+
+	return old, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+/*
+#include <stdlib.h>
+#include "lseco_ffi.h"
+
+// Plain, unlocked allocations for WithTestMode.
+static void* lseco_test_mode_alloc(size_t size, size_t alignment, void* ctx) {
+    (void)ctx;
+#ifdef _WIN32
+    return _aligned_malloc(size, alignment);
+#else
+    void* ptr = NULL;
+    return posix_memalign(&ptr, alignment, size) == 0 ? ptr : NULL;
+#endif
+}
+
+static void lseco_test_mode_free(void* ptr, size_t size, void* ctx) {
+    (void)size;
+    (void)ctx;
+#ifdef _WIN32
+    _aligned_free(ptr);
+#else
+    free(ptr);
+#endif
+}
+
+static lseco_allocator_t lseco_test_mode_allocator(void) {
+    lseco_allocator_t allocator = {lseco_test_mode_alloc, lseco_test_mode_free, NULL};
+    return allocator;
+}
+*/
+import "C"
+import (
+	"fmt"
+	"os"
+)
+
+// WithTestMode allocates the storage from the ordinary C heap without
+// mlock, so unit tests can run in CI environments whose RLIMIT_MEMLOCK is
+// too low for real storages. Everything else, including page protection
+// and integrity tags, behaves as usual, but the content may be swapped to
+// disk, so a warning is printed to os.Stderr whenever such a storage is
+// created. It replaces any WithAllocator allocator. Never use it outside
+// tests.
+func WithTestMode() Option {
+	return func(c *storageConfig) {
+		c.allocator = &customAllocator{callbacks: C.lseco_test_mode_allocator()}
+		c.testMode = true
+	}
+}
+
+// NewSecureStorageTestMode is NewSecureStorage with WithTestMode.
+func NewSecureStorageTestMode(size int, opts ...Option) (*SecureStorage, error) {
+	return NewSecureStorage(size, append(opts, WithTestMode())...)
+}
+
+// warnTestMode prints the warning required by WithTestMode.
+func warnTestMode(size int) {
+	fmt.Fprintf(os.Stderr, "lseco: WARNING: test mode storage of %d bytes is not mlock-ed; never use it for real secrets\n", size)
+}
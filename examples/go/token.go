@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AccessToken is an application token: a secret held in a SecureStorage
+// together with its expiry and the purpose it was issued for. The secret
+// is stored with StoreWithTTL, so it is also wiped from memory once the
+// token expires.
+type AccessToken struct {
+	*SecureStorage
+
+	ExpiresAt time.Time
+	Purpose   string
+}
+
+// NewAccessToken stores secret in a new SecureStorage of len(secret)
+// bytes, created with opts, that expires after ttl. The caller should
+// zero secret afterwards.
+func NewAccessToken(secret []byte, ttl time.Duration, purpose string, opts ...Option) (*AccessToken, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("secret must not be empty")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("ttl must be positive, got %v", ttl)
+	}
+
+	s, err := NewSecureStorage(len(secret), opts...)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(ttl)
+	if err := s.StoreWithTTL(secret, ttl); err != nil {
+		s.Destroy()
+		return nil, err
+	}
+
+	return &AccessToken{
+		SecureStorage: s,
+		ExpiresAt:     expiresAt,
+		Purpose:       purpose,
+	}, nil
+}
+
+// IsExpired reports whether the token has reached ExpiresAt.
+func (t *AccessToken) IsExpired() bool {
+	return !time.Now().Before(t.ExpiresAt)
+}
+
+// RemainingTTL returns the time left until ExpiresAt, or zero once the
+// token has expired.
+func (t *AccessToken) RemainingTTL() time.Duration {
+	return max(time.Until(t.ExpiresAt), 0)
+}
+
+// Retrieve retrieves the secret like SecureStorage.Retrieve, but returns
+// ErrTokenExpired once the token has expired.
+func (t *AccessToken) Retrieve(length int) ([]byte, error) {
+	if t.IsExpired() {
+		return nil, ErrTokenExpired
+	}
+
+	data, err := t.SecureStorage.Retrieve(length)
+	if errors.Is(err, ErrExpired) {
+		return nil, ErrTokenExpired
+	}
+	return data, err
+}
@@ -0,0 +1,22 @@
+package main
+
+import "context"
+
+// spanStarter is the hook a tracing integration installs in
+// storageConfig. The OpenTelemetry one lives in tracing_otel.go, behind
+// the lseco_otel build tag, so default builds do not link otel at all.
+type spanStarter interface {
+	// start begins a span for op. The returned func ends it with the
+	// byte length the operation worked on and its error, if any.
+	start(ctx context.Context, op storageOp) func(length int, err error)
+}
+
+// startSpan begins a span for op if a tracer is configured.
+func (s *SecureStorage) startSpan(ctx context.Context, op storageOp) func(length int, err error) {
+	if s.cfg.spans == nil {
+		return endNoSpan
+	}
+	return s.cfg.spans.start(ctx, op)
+}
+
+func endNoSpan(int, error) {}
@@ -0,0 +1,45 @@
+//go:build lseco_otel
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer emits a span from t for every Store, Retrieve, and Destroy.
+// StoreCtx and RetrieveCtx parent their span on the given context. Spans
+// carry the operation name, the byte length, and on failure the lseco
+// error code; the stored content is never recorded.
+//
+// WithTracer is only available when built with -tags lseco_otel.
+func WithTracer(t trace.Tracer) Option {
+	return func(c *storageConfig) {
+		c.spans = otelSpans{tracer: t}
+	}
+}
+
+type otelSpans struct {
+	tracer trace.Tracer
+}
+
+func (o otelSpans) start(ctx context.Context, op storageOp) func(length int, err error) {
+	_, span := o.tracer.Start(ctx, "lseco."+op.String(),
+		trace.WithAttributes(attribute.String("lseco.operation", op.String())))
+
+	return func(length int, err error) {
+		span.SetAttributes(attribute.Int("lseco.length", length))
+		if err != nil {
+			var code ErrCode
+			if errors.As(err, &code) {
+				span.SetAttributes(attribute.Int("lseco.error_code", int(code)))
+			}
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "fmt"
+
+// Validate calls validator with the stored content (Len() bytes), e.g. to
+// check that a secret parses as a PEM key or JWT, or has the right length
+// for a cipher, and returns the validator's error. The slice is backed by
+// a locked C buffer holding a copy of the content, which is zeroed and
+// freed as soon as validator returns, even if it panics; the content of
+// WithCompress and envelope encrypted storages is decoded first.
+//
+// Unlike ExportLocked, changes validator makes to the slice do not reach
+// the storage. validator must not retain the slice or call back into the
+// storage. The call counts as one retrieval for WithMaxRetrievals.
+func (s *SecureStorage) Validate(validator func([]byte) error) error {
+	if validator == nil {
+		return fmt.Errorf("validator cannot be nil")
+	}
+
+	key, err := s.lockWithDataKey(false)
+	if err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+	defer key.Close()
+
+	n := s.usedLocked()
+	if n == 0 {
+		return fmt.Errorf("no data stored")
+	}
+	b, err := s.retrieveBufferLocked(n, key)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+	s.record(opRetrieve)
+
+	return validator(b.Bytes()[:n:n])
+}
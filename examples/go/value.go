@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding"
+	"fmt"
+)
+
+// StoreValue marshals v and stores the result like Store, so typed
+// secrets such as a *url.URL or a custom struct need no manual
+// conversion. The intermediate encoding is zeroed as soon as it has been
+// copied into C memory; whatever v.MarshalBinary keeps internally is
+// outside lseco's control.
+func (s *SecureStorage) StoreValue(v encoding.BinaryMarshaler) error {
+	data, err := v.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+	defer SecureZero(data)
+
+	return s.Store(data)
+}
+
+// RetrieveValue decodes the stored content into v. The content is staged
+// in a locked SecureBuffer that is zeroed when UnmarshalBinary returns,
+// so the encoding itself never reaches the Go heap; per the
+// encoding.BinaryUnmarshaler contract, v must copy anything it keeps.
+//
+// Retrieving a value counts as one retrieval.
+func (s *SecureStorage) RetrieveValue(v encoding.BinaryUnmarshaler) error {
+	key, err := s.lockWithDataKey(false)
+	if err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+	defer key.Close()
+
+	if s.length == 0 {
+		return fmt.Errorf("no data stored")
+	}
+	b, err := s.retrieveBufferLocked(s.usedLocked(), key)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+	s.record(opRetrieve)
+
+	if err := v.UnmarshalBinary(b.Bytes()); err != nil {
+		return fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// VaultTransitConfig configures a VaultTransitStorage.
+type VaultTransitConfig struct {
+	// Address is the Vault server URL, e.g. "https://vault:8200".
+	Address string
+	// Token authenticates every request as X-Vault-Token.
+	Token string
+	// Namespace is sent as X-Vault-Namespace if set (Vault Enterprise).
+	Namespace string
+	// Mount is the path the Transit engine is mounted at; "transit" if
+	// empty.
+	Mount string
+	// KeyName is the Transit key used to encrypt and decrypt.
+	KeyName string
+	// HTTPClient sends the requests; http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// VaultTransitStorage offers the Store, Retrieve, and Destroy methods of
+// SecureStorage, but keeps only Vault Transit ciphertext locally: Store
+// encrypts through the Transit encrypt endpoint and Retrieve decrypts
+// through the decrypt endpoint, so the key never leaves Vault and the
+// plaintext is never held at rest. It is safe for concurrent use by
+// multiple goroutines.
+//
+// Unlike SecureStorage, the plaintext does pass through the Go heap on
+// its way to and from Vault. The request and response buffers lseco owns
+// are zeroed after use, but net/http and TLS buffers are out of its
+// reach.
+type VaultTransitStorage struct {
+	mu         sync.Mutex
+	cfg        VaultTransitConfig
+	ciphertext string
+	length     int
+	destroyed  bool
+}
+
+// NewVaultTransitStorage returns an empty storage that encrypts under the
+// Transit key cfg.KeyName.
+func NewVaultTransitStorage(cfg VaultTransitConfig) (*VaultTransitStorage, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("vault address is required")
+	}
+	if cfg.KeyName == "" {
+		return nil, fmt.Errorf("vault transit key name is required")
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = "transit"
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	cfg.Address = strings.TrimRight(cfg.Address, "/")
+
+	return &VaultTransitStorage{cfg: cfg}, nil
+}
+
+// Store encrypts data through Vault and keeps the resulting ciphertext,
+// replacing any previous one.
+func (v *VaultTransitStorage) Store(data []byte) error {
+	return v.StoreCtx(context.Background(), data)
+}
+
+// StoreCtx stores data like Store, with ctx bounding the Vault request.
+func (v *VaultTransitStorage) StoreCtx(ctx context.Context, data []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.destroyed {
+		return ErrVaultTransitDestroyed
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("data must not be empty")
+	}
+
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	// A []byte field is base64-encoded by encoding/json, which is the
+	// form Transit expects.
+	req := struct {
+		Plaintext []byte `json:"plaintext"`
+	}{data}
+	if err := v.callLocked(ctx, "encrypt", req, &resp); err != nil {
+		return err
+	}
+	if resp.Data.Ciphertext == "" {
+		return fmt.Errorf("vault transit encrypt: empty ciphertext")
+	}
+
+	v.ciphertext = resp.Data.Ciphertext
+	v.length = len(data)
+	return nil
+}
+
+// Retrieve decrypts the stored ciphertext through Vault and returns its
+// first length bytes. The caller should zero the result after use.
+func (v *VaultTransitStorage) Retrieve(length int) ([]byte, error) {
+	return v.RetrieveCtx(context.Background(), length)
+}
+
+// RetrieveCtx retrieves data like Retrieve, with ctx bounding the Vault
+// request.
+func (v *VaultTransitStorage) RetrieveCtx(ctx context.Context, length int) ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.destroyed {
+		return nil, ErrVaultTransitDestroyed
+	}
+	if v.ciphertext == "" {
+		return nil, fmt.Errorf("no data stored")
+	}
+	if length <= 0 || length > v.length {
+		return nil, fmt.Errorf("invalid length %d (max: %d)", length, v.length)
+	}
+
+	var resp struct {
+		Data struct {
+			Plaintext []byte `json:"plaintext"`
+		} `json:"data"`
+	}
+	req := struct {
+		Ciphertext string `json:"ciphertext"`
+	}{v.ciphertext}
+	if err := v.callLocked(ctx, "decrypt", req, &resp); err != nil {
+		return nil, err
+	}
+
+	plaintext := resp.Data.Plaintext
+	if len(plaintext) < length {
+		SecureZero(plaintext)
+		return nil, fmt.Errorf("vault transit decrypt: got %d bytes, want %d", len(plaintext), length)
+	}
+	SecureZero(plaintext[length:])
+	return plaintext[:length:length], nil
+}
+
+// Used returns the length of the plaintext last stored.
+func (v *VaultTransitStorage) Used() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.length
+}
+
+// Destroy drops the local ciphertext. The Transit key itself is left in
+// Vault. It is safe to call more than once.
+func (v *VaultTransitStorage) Destroy() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.ciphertext = ""
+	v.length = 0
+	v.destroyed = true
+}
+
+// IsDestroyed reports whether Destroy has been called.
+func (v *VaultTransitStorage) IsDestroyed() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.destroyed
+}
+
+// callLocked POSTs req to the Transit op endpoint for the configured key
+// and decodes the response into resp. Both bodies are zeroed afterwards,
+// since either may carry plaintext.
+func (v *VaultTransitStorage) callLocked(ctx context.Context, op string, req, resp any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	defer SecureZero(body)
+
+	endpoint := v.cfg.Address + "/v1/" + strings.Trim(v.cfg.Mount, "/") + "/" + op + "/" + url.PathEscape(v.cfg.KeyName)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Vault-Token", v.cfg.Token)
+	if v.cfg.Namespace != "" {
+		httpReq.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+
+	httpResp, err := v.cfg.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("vault transit %s: %w", op, err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	defer SecureZero(respBody)
+	if err != nil {
+		return fmt.Errorf("vault transit %s: %w", op, err)
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("vault transit %s: %s: %s", op, httpResp.Status, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("vault transit %s: %s", op, httpResp.Status)
+	}
+
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return fmt.Errorf("vault transit %s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LibraryVersion parses the semantic version reported by the C library.
+// label holds any pre-release or build suffix ("beta.1" for
+// "1.2.0-beta.1"), or "" for a plain release.
+func LibraryVersion() (major, minor, patch int, label string, err error) {
+	raw := C.GoString(C.lseco_version())
+
+	core := raw
+	if i := strings.IndexAny(raw, "-+"); i >= 0 {
+		core, label = raw[:i], raw[i+1:]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return 0, 0, 0, "", fmt.Errorf("malformed library version %q", raw)
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		if nums[i], err = strconv.Atoi(p); err != nil || nums[i] < 0 {
+			return 0, 0, 0, "", fmt.Errorf("malformed library version %q", raw)
+		}
+	}
+	return nums[0], nums[1], nums[2], label, nil
+}
+
+// BuildInfo describes how the C library was built: compiler, platform,
+// zeroing primitive, core dump exclusion, and compiler flags, as
+// "key=value" pairs separated by "; ".
+func BuildInfo() string {
+	return C.GoString(C.lseco_build_info())
+}
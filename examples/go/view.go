@@ -0,0 +1,79 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import "unsafe"
+
+// withViewLocked maps the C buffer and calls fn with a slice backed
+// directly by it, so fn can process secret bytes without copying them
+// into Go memory. The slice must not be retained or used after fn
+// returns: access is revoked as soon as fn is done.
+func (s *SecureStorage) withViewLocked(fn func(view []byte) error) error {
+	return s.mapLocked(func(region []byte) error {
+		return fn(region[:s.size:s.size])
+	})
+}
+
+// mapLocked is like withViewLocked, but the slice covers the whole
+// allocation, including the integrity tag after the first s.size bytes.
+func (s *SecureStorage) mapLocked(fn func(region []byte) error) (err error) {
+	// The address goes through a field rather than a local, which would
+	// escape to the heap on every call once passed to C.
+	result := C.lseco_acquire(s.handle, &s.mapped)
+	ptr := s.mapped
+	s.mapped = nil
+	if result != C.LSECO_SUCCESS {
+		return resultError("acquire", result)
+	}
+	defer func() {
+		if result := C.lseco_release(s.handle); result != C.LSECO_SUCCESS && err == nil {
+			err = resultError("release", result)
+		}
+	}()
+
+	return fn(unsafe.Slice((*byte)(ptr), s.size+integrityTagSize))
+}
+
+// ExportLocked calls fn with a slice backed directly by the locked C
+// memory, holding the stored data (Used() bytes), so the secret can be
+// inspected without copying it onto the Go heap. The storage stays
+// write-locked while fn runs and access is revoked as soon as it returns,
+// even if fn panics.
+//
+// fn must not retain the slice, or anything sliced from it, and must not
+// call back into the storage: once fn returns, the pages are no longer
+// accessible and touching a retained slice crashes the process. The call
+// counts as one retrieval for WithMaxRetrievals and is reported to the
+// AuditHandler as a retrieve.
+func (s *SecureStorage) ExportLocked(fn func([]byte) error) (err error) {
+	if err := checkRaw(s); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return err
+	}
+
+	// fn may modify the content in place, so retag even if it panics.
+	exported := false
+	defer func() {
+		if retagErr := s.retagLocked(); err == nil {
+			err = retagErr
+		}
+		if exported {
+			s.countRetrievalLocked()
+		}
+	}()
+
+	return s.withViewLocked(func(view []byte) error {
+		exported = true
+		s.record(opRetrieve)
+
+		return fn(view[:s.length:s.length])
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+// Watch returns a channel that receives a value after each successful
+// Store, StoreWithTTL, TryStore, or AtomicSwap, and a cancel function that
+// closes it. Signals carry no content and are coalesced: a watcher that
+// falls behind sees one pending signal, never a blocked Store. The
+// channel is also closed by Destroy, so a watcher can range over it.
+// Cancel is safe to call more than once.
+func (s *SecureStorage) Watch() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.handle == nil {
+		close(ch)
+		return ch, func() {}
+	}
+	s.watchers.Store(ch, struct{}{})
+
+	return ch, func() {
+		// Taking mu keeps a concurrent Store from sending on ch after it
+		// has been closed.
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if _, ok := s.watchers.LoadAndDelete(ch); ok {
+			close(ch)
+		}
+	}
+}
+
+// notifyWatchersLocked signals every channel returned by Watch without
+// blocking.
+func (s *SecureStorage) notifyWatchersLocked() {
+	s.watchers.Range(func(key, _ any) bool {
+		select {
+		case key.(chan struct{}) <- struct{}{}:
+		default:
+		}
+		return true
+	})
+}
+
+// closeWatchersLocked closes and forgets every channel returned by Watch.
+func (s *SecureStorage) closeWatchersLocked() {
+	s.watchers.Range(func(key, _ any) bool {
+		if _, ok := s.watchers.LoadAndDelete(key); ok {
+			close(key.(chan struct{}))
+		}
+		return true
+	})
+}
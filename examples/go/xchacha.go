@@ -0,0 +1,66 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// XChaCha20Encrypt encrypts the stored content with XChaCha20-Poly1305
+// under key, a storage holding exactly 32 bytes, e.g. an ephemeral
+// per-message key for forward secrecy in a message queue. The cipher
+// runs in the C layer, so neither the plaintext, the key, nor the derived
+// subkey ever leaves locked memory; only the returned ciphertext, with
+// its 16-byte tag appended, is an ordinary []byte. No additional data is
+// authenticated, and the output opens with
+// golang.org/x/crypto/chacha20poly1305.NewX or libsodium.
+//
+// nonce must be 24 bytes and never repeat under the same key; random
+// nonces are safe at this size. It returns ErrInvalidNonce for any other
+// length. Both storages are left unchanged, and each counts one
+// retrieval.
+func (s *SecureStorage) XChaCha20Encrypt(key *SecureStorage, nonce []byte) ([]byte, error) {
+	if len(nonce) != C.LSECO_XCHACHA20_NONCE_SIZE {
+		return nil, fmt.Errorf("%w: %d bytes, want %d", ErrInvalidNonce, len(nonce), C.LSECO_XCHACHA20_NONCE_SIZE)
+	}
+	if err := checkRaw(s, key); err != nil {
+		return nil, err
+	}
+
+	unlock := lockPair(s, key)
+	defer unlock()
+
+	if s.length == 0 {
+		return nil, fmt.Errorf("no data stored")
+	}
+	if key.length != C.LSECO_XCHACHA20_KEY_SIZE {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", C.LSECO_XCHACHA20_KEY_SIZE, key.length)
+	}
+
+	if err := s.checkRetrievalLocked(); err != nil {
+		return nil, err
+	}
+	if key != s {
+		if err := key.checkRetrievalLocked(); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]byte, s.length+C.LSECO_POLY1305_TAG_SIZE)
+	result := C.lseco_xchacha20poly1305_seal(s.handle, C.size_t(s.length), key.handle,
+		unsafe.Pointer(&nonce[0]), unsafe.Pointer(&out[0]))
+	if result != C.LSECO_SUCCESS {
+		return nil, resultError("xchacha20-poly1305 encrypt", result)
+	}
+
+	s.countRetrievalLocked()
+	s.record(opRetrieve)
+	if key != s {
+		key.countRetrievalLocked()
+		key.record(opRetrieve)
+	}
+	return out, nil
+}
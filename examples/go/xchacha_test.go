@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+func TestXChaCha20Encrypt(t *testing.T) {
+	rawKey := bytes.Repeat([]byte{0x42}, chacha20poly1305.KeySize)
+	key := newTestStorage(t, chacha20poly1305.KeySize)
+	mustStore(t, key, bytes.Clone(rawKey))
+	data := []byte("queue message")
+	s := newTestStorage(t, 64)
+	mustStore(t, s, data)
+
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatalf("rand.Read failed: %v", err)
+	}
+	ciphertext, err := s.XChaCha20Encrypt(key, nonce)
+	if err != nil {
+		t.Fatalf("XChaCha20Encrypt failed: %v", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(rawKey)
+	if err != nil {
+		t.Fatalf("NewX failed: %v", err)
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if !bytes.Equal(plaintext, data) {
+		t.Fatalf("Open = %q, want %q", plaintext, data)
+	}
+	requireContent(t, s, data)
+
+	if _, err := s.XChaCha20Encrypt(key, nonce[:12]); !errors.Is(err, ErrInvalidNonce) {
+		t.Fatalf("XChaCha20Encrypt with a 12-byte nonce = %v, want ErrInvalidNonce", err)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+/*
+#include "lseco_ffi.h"
+*/
+import "C"
+import (
+	"fmt"
+	"unsafe"
+)
+
+// XOR applies mask to s in place as a one-time pad, XOR-ing the two C
+// buffers byte by byte in the C layer so neither touches the Go heap.
+// Applying the same mask twice restores the original content. It returns
+// ErrSizeMismatch if s.Used() and mask.Used() differ.
+//
+// The operation counts as one retrieval from mask for WithMaxRetrievals
+// and as a store to s.
+func (s *SecureStorage) XOR(mask *SecureStorage) error {
+	if err := checkRaw(s, mask); err != nil {
+		return err
+	}
+
+	unlock := lockPair(s, mask)
+	defer unlock()
+
+	if s.length != mask.length {
+		return fmt.Errorf("%w: %d != %d", ErrSizeMismatch, s.length, mask.length)
+	}
+	if s.length == 0 {
+		return fmt.Errorf("storage is empty")
+	}
+	// Retagging below would otherwise bless content altered outside the
+	// library.
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
+	if err := mask.checkRetrievalLocked(); err != nil {
+		return err
+	}
+
+	result := C.lseco_xor(s.handle, mask.handle, C.size_t(s.length))
+	if result != C.LSECO_SUCCESS {
+		return resultError("xor", result)
+	}
+	mask.countRetrievalLocked()
+
+	if err := s.retagLocked(); err != nil {
+		return err
+	}
+
+	mask.record(opRetrieve)
+	s.record(opStore)
+	return nil
+}
+
+// ObfuscateXOR XORs the content in place with key, repeating key as
+// needed, inside the C layer. It exists only for legacy protocols that
+// obfuscate data in flight this way: a repeating XOR key is recovered
+// from any known plaintext, so this is NOT encryption and must never be
+// relied on to protect a secret. Applying the same key again restores the
+// original content. The key slice is not retained.
+func (s *SecureStorage) ObfuscateXOR(key []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("key must not be empty")
+	}
+	if err := checkRaw(s); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.length == 0 {
+		return fmt.Errorf("storage is empty")
+	}
+	if err := s.verifyLocked(); err != nil {
+		return err
+	}
+
+	result := C.lseco_xor_obfuscate(s.handle, unsafe.Pointer(&key[0]), C.size_t(len(key)), C.size_t(s.length))
+	if result != C.LSECO_SUCCESS {
+		return resultError("xor obfuscate", result)
+	}
+	if err := s.retagLocked(); err != nil {
+		return err
+	}
+
+	s.record(opStore)
+	return nil
+}
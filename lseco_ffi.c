@@ -22,6 +22,73 @@ LSECO_API lseco_handle_t lseco_create(size_t size) {
     return (lseco_handle_t)handle;
 }
 
+/* FFI wrapper: Create aligned storage */
+LSECO_API lseco_handle_t lseco_create_aligned(size_t size, size_t alignment) {
+    /* Input validation */
+    if (size == 0 || (alignment & (alignment - 1)) != 0) {
+        return NULL;
+    }
+    
+    secure_memory_t* handle = NULL;
+    int result = secure_memory_create_aligned(&handle, size, alignment);
+    
+    if (result != SECURE_SUCCESS) {
+        return NULL;
+    }
+    
+    return (lseco_handle_t)handle;
+}
+
+/* FFI wrapper: Create storage from a custom allocator */
+LSECO_API lseco_handle_t lseco_create_with_allocator(size_t size, size_t alignment,
+                                                     const lseco_allocator_t* allocator) {
+    /* Input validation */
+    if (allocator == NULL || allocator->alloc == NULL || allocator->free == NULL) {
+        return NULL;
+    }
+    if (size == 0 || (alignment & (alignment - 1)) != 0) {
+        return NULL;
+    }
+    
+    secure_allocator_t mem_allocator = {allocator->alloc, allocator->free, allocator->ctx};
+    secure_memory_t* handle = NULL;
+    int result = secure_memory_create_with_allocator(&handle, size, alignment, &mem_allocator);
+    
+    if (result != SECURE_SUCCESS) {
+        return NULL;
+    }
+    
+    return (lseco_handle_t)handle;
+}
+
+/* FFI wrapper: Create storage surrounded by guard pages */
+LSECO_API lseco_handle_t lseco_create_guarded(size_t size, size_t alignment,
+                                              const lseco_allocator_t* allocator) {
+    /* Input validation */
+    if (allocator != NULL && (allocator->alloc == NULL || allocator->free == NULL)) {
+        return NULL;
+    }
+    if (size == 0 || (alignment & (alignment - 1)) != 0) {
+        return NULL;
+    }
+    
+    secure_allocator_t mem_allocator;
+    if (allocator != NULL) {
+        mem_allocator.alloc = allocator->alloc;
+        mem_allocator.free = allocator->free;
+        mem_allocator.ctx = allocator->ctx;
+    }
+    secure_memory_t* handle = NULL;
+    int result = secure_memory_create_guarded(&handle, size, alignment,
+                                              allocator != NULL ? &mem_allocator : NULL, 1);
+    
+    if (result != SECURE_SUCCESS) {
+        return NULL;
+    }
+    
+    return (lseco_handle_t)handle;
+}
+
 /* FFI wrapper: Store data */
 LSECO_API int lseco_store(lseco_handle_t handle, const void* data, size_t length) {
     /* Input validation - prevent DoS, never call exit/abort */
@@ -56,6 +123,215 @@ LSECO_API int lseco_retrieve(lseco_handle_t handle, void* buffer, size_t length)
     return secure_memory_read(mem, buffer, length);
 }
 
+/* FFI wrapper: Store data at offset */
+LSECO_API int lseco_store_at(lseco_handle_t handle, size_t offset, const void* data, size_t length) {
+    /* Input validation */
+    if (handle == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (data == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    secure_memory_t* mem = (secure_memory_t*)handle;
+    return secure_memory_write_at(mem, offset, data, length);
+}
+
+/* FFI wrapper: Retrieve data at offset */
+LSECO_API int lseco_retrieve_at(lseco_handle_t handle, size_t offset, void* buffer, size_t length) {
+    /* Input validation */
+    if (handle == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (buffer == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    secure_memory_t* mem = (secure_memory_t*)handle;
+    return secure_memory_read_at(mem, offset, buffer, length);
+}
+
+/* FFI wrapper: Zero a range */
+LSECO_API int lseco_wipe_at(lseco_handle_t handle, size_t offset, size_t length) {
+    /* Input validation */
+    if (handle == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    secure_memory_t* mem = (secure_memory_t*)handle;
+    return secure_memory_wipe_at(mem, offset, length);
+}
+
+/* FFI wrapper: Receive from a socket at offset */
+LSECO_API int lseco_recv_at(lseco_handle_t handle, size_t offset, intptr_t fd,
+                            size_t length, size_t* received) {
+    /* Input validation */
+    if (handle == NULL || received == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    secure_memory_t* mem = (secure_memory_t*)handle;
+    return secure_memory_recv_at(mem, offset, fd, length, received);
+}
+
+/* FFI wrapper: Copy between storages */
+LSECO_API int lseco_copy_at(lseco_handle_t dst, size_t dst_offset,
+                            lseco_handle_t src, size_t src_offset, size_t length) {
+    /* Input validation */
+    if (dst == NULL || src == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    return secure_memory_copy_at((secure_memory_t*)dst, dst_offset,
+                                 (const secure_memory_t*)src, src_offset, length);
+}
+
+/* FFI wrapper: Constant-time compare */
+LSECO_API int lseco_compare(lseco_handle_t a, lseco_handle_t b, size_t length, int* equal) {
+    /* Input validation */
+    if (a == NULL || b == NULL || equal == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    return secure_memory_compare((const secure_memory_t*)a, (const secure_memory_t*)b,
+                                 length, equal);
+}
+
+/* FFI wrapper: Constant-time comparison with a buffer */
+LSECO_API int lseco_compare_bytes(lseco_handle_t handle, const void* data, size_t length, int* equal) {
+    /* Input validation */
+    if (handle == NULL || data == NULL || equal == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    return secure_memory_compare_bytes((const secure_memory_t*)handle, data, length, equal);
+}
+
+/* FFI wrapper: Constant-time per-byte difference */
+LSECO_API int lseco_diff(lseco_handle_t a, lseco_handle_t b, size_t length, unsigned char* changed) {
+    /* Input validation */
+    if (a == NULL || b == NULL || changed == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    return secure_memory_diff((const secure_memory_t*)a, (const secure_memory_t*)b, length, changed);
+}
+
+/* FFI wrapper: Constant-time copy */
+LSECO_API int lseco_ct_copy(lseco_handle_t dst, lseco_handle_t src, size_t length) {
+    /* Input validation */
+    if (dst == NULL || src == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    return secure_memory_ct_copy((secure_memory_t*)dst, (const secure_memory_t*)src, length);
+}
+
+/* FFI wrapper: XOR mask in place */
+LSECO_API int lseco_xor(lseco_handle_t dst, lseco_handle_t mask, size_t length) {
+    /* Input validation */
+    if (dst == NULL || mask == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    return secure_memory_xor((secure_memory_t*)dst, (const secure_memory_t*)mask, length);
+}
+
+/* FFI wrapper: XOR with a repeating key */
+LSECO_API int lseco_xor_obfuscate(lseco_handle_t handle, const void* key,
+                                  size_t key_len, size_t length) {
+    /* Input validation */
+    if (handle == NULL || key == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (key_len == 0 || length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    return secure_memory_xor_obfuscate((secure_memory_t*)handle, key, key_len, length);
+}
+
+/* FFI wrapper: XChaCha20-Poly1305 encryption */
+LSECO_API int lseco_xchacha20poly1305_seal(lseco_handle_t plaintext, size_t length, lseco_handle_t key,
+                                           const void* nonce, void* out) {
+    /* Input validation */
+    if (plaintext == NULL || key == NULL || nonce == NULL || out == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (length == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    return secure_memory_xchacha20poly1305_seal((const secure_memory_t*)plaintext, length,
+                                                (const secure_memory_t*)key, nonce, out);
+}
+
+/* FFI wrapper: Resize */
+LSECO_API int lseco_resize(lseco_handle_t handle, size_t new_size) {
+    /* Input validation */
+    if (handle == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    if (new_size == 0) {
+        return LSECO_ERR_INVALID_SIZE;
+    }
+    
+    secure_memory_t* mem = (secure_memory_t*)handle;
+    return secure_memory_resize(mem, new_size);
+}
+
+/* FFI wrapper: Map for direct access */
+LSECO_API int lseco_acquire(lseco_handle_t handle, void** data) {
+    /* Input validation */
+    if (handle == NULL || data == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    
+    secure_memory_t* mem = (secure_memory_t*)handle;
+    return secure_memory_acquire(mem, data);
+}
+
+/* FFI wrapper: Revoke direct access */
+LSECO_API int lseco_release(lseco_handle_t handle) {
+    /* Input validation */
+    if (handle == NULL) {
+        return LSECO_ERR_NULL_PTR;
+    }
+    
+    secure_memory_t* mem = (secure_memory_t*)handle;
+    return secure_memory_release(mem);
+}
+
 /* FFI wrapper: Get size */
 LSECO_API size_t lseco_get_size(lseco_handle_t handle) {
     /* NULL check */
@@ -78,6 +354,43 @@ LSECO_API void lseco_destroy(lseco_handle_t handle) {
     secure_memory_destroy(&mem);
 }
 
+/* FFI wrapper: Create locked buffer */
+LSECO_API void* lseco_buffer_create(size_t size) {
+    /* Input validation */
+    if (size == 0) {
+        return NULL;
+    }
+    
+    void* buffer = NULL;
+    int result = secure_buffer_alloc(&buffer, size);
+    
+    if (result != SECURE_SUCCESS) {
+        return NULL;
+    }
+    
+    return buffer;
+}
+
+/* FFI wrapper: Destroy locked buffer */
+LSECO_API void lseco_buffer_destroy(void* buffer, size_t size) {
+    /* Safe to call with NULL */
+    if (buffer == NULL) {
+        return;
+    }
+    
+    secure_buffer_free(buffer, size);
+}
+
+/* FFI utility: Secure zero */
+LSECO_API void lseco_secure_zero(void* ptr, size_t length) {
+    /* Safe to call with NULL */
+    if (ptr == NULL) {
+        return;
+    }
+    
+    secure_memory_zero(ptr, length);
+}
+
 /* FFI utility: Error string */
 LSECO_API const char* lseco_error_string(int error_code) {
     switch (error_code) {
@@ -93,6 +406,8 @@ LSECO_API const char* lseco_error_string(int error_code) {
             return "Failed to set memory protection";
         case LSECO_ERR_INVALID_SIZE:
             return "Invalid size parameter";
+        case LSECO_ERR_IO:
+            return "I/O error";
         default:
             return "Unknown error";
     }
@@ -102,3 +417,13 @@ LSECO_API const char* lseco_error_string(int error_code) {
 LSECO_API const char* lseco_version(void) {
     return LSECO_VERSION;
 }
+
+/* FFI utility: Build info */
+LSECO_API const char* lseco_build_info(void) {
+    return secure_memory_build_info();
+}
+
+/* FFI utility: SGX enclave support (no enclave backend is built in) */
+LSECO_API int lseco_sgx_available(void) {
+    return 0;
+}
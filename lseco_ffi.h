@@ -26,10 +26,26 @@ extern "C" {
 #define LSECO_ERR_LOCK_FAILED   -3
 #define LSECO_ERR_PROTECT_FAILED -4
 #define LSECO_ERR_INVALID_SIZE  -5
+#define LSECO_ERR_IO            -6
+
+/* XChaCha20-Poly1305 parameter sizes (same as secure_memory.h) */
+#define LSECO_XCHACHA20_KEY_SIZE   32
+#define LSECO_XCHACHA20_NONCE_SIZE 24
+#define LSECO_POLY1305_TAG_SIZE    16
 
 /* Opaque handle for FFI use */
 typedef void* lseco_handle_t;
 
+/* Custom allocator for lseco_create_with_allocator */
+typedef struct lseco_allocator_t {
+    /* Return size bytes aligned to alignment and locked in RAM, or NULL */
+    void* (*alloc)(size_t size, size_t alignment, void* ctx);
+    /* Release memory returned by alloc; it has already been zeroed */
+    void (*free)(void* ptr, size_t size, void* ctx);
+    /* Passed to both callbacks */
+    void* ctx;
+} lseco_allocator_t;
+
 /**
  * @brief Create a secure storage for sensitive data
  * 
@@ -46,6 +62,65 @@ typedef void* lseco_handle_t;
  */
 LSECO_API lseco_handle_t lseco_create(size_t size);
 
+/**
+ * @brief Create a secure storage aligned for SIMD or hardware primitives
+ * 
+ * Same as lseco_create, but the storage starts at a multiple of alignment.
+ * Storage from lseco_create is already page-aligned, which satisfies any
+ * alignment up to the page size (16 bytes for AES-NI, 64 for AVX-512);
+ * larger alignments such as huge page boundaries are honored as well.
+ * 
+ * @param size Size in bytes to allocate (must be > 0)
+ * @param alignment Power of two (0 means page alignment)
+ * @return Handle to secure storage on success, NULL on failure
+ * 
+ * Example (Go):
+ *   handle := C.lseco_create_aligned(256, 64)
+ */
+LSECO_API lseco_handle_t lseco_create_aligned(size_t size, size_t alignment);
+
+/**
+ * @brief Create a secure storage from a custom allocator
+ * 
+ * Same as lseco_create_aligned, but memory comes from allocator, e.g.
+ * PKCS#11 HSM memory or a pre-reserved arena. The library does not mlock
+ * it: alloc must return memory that is already locked in RAM and at
+ * least page-aligned, since access is still revoked between operations.
+ * lseco_resize allocates from the same allocator, and lseco_destroy zeroes
+ * the memory before calling free.
+ * 
+ * @param size Size in bytes to allocate (must be > 0)
+ * @param alignment Power of two (0 means page alignment)
+ * @param allocator Allocator callbacks, copied into the handle (alloc and free must not be NULL)
+ * @return Handle to secure storage on success, NULL on failure
+ * 
+ * Example (Go):
+ *   handle := C.lseco_create_with_allocator(256, 0, &allocator)
+ */
+LSECO_API lseco_handle_t lseco_create_with_allocator(size_t size, size_t alignment,
+                                                     const lseco_allocator_t* allocator);
+
+/**
+ * @brief Create a secure storage surrounded by guard pages
+ * 
+ * Same as lseco_create_with_allocator, but one inaccessible page is placed
+ * directly before and after the storage for its whole lifetime, so an
+ * overflow out of it crashes with SIGSEGV instead of corrupting memory
+ * silently. Each storage costs two extra pages of address space (not
+ * locked), so this is meant for tests and debugging. lseco_resize keeps
+ * the guard pages.
+ * 
+ * @param size Size in bytes to allocate (must be > 0)
+ * @param alignment Power of two (0 means page alignment)
+ * @param allocator Allocator callbacks, or NULL for the built-in allocator
+ * @return Handle to secure storage on success, NULL on failure
+ * 
+ * Example (Go):
+ *   handle := C.lseco_create_guarded(256, 0, nil)
+ */
+LSECO_API lseco_handle_t lseco_create_guarded(size_t size, size_t alignment,
+                                              const lseco_allocator_t* allocator);
+
 /**
  * @brief Store sensitive data in secure storage
  * 
@@ -82,6 +157,282 @@ LSECO_API int lseco_store(lseco_handle_t handle, const void* data, size_t length
  */
 LSECO_API int lseco_retrieve(lseco_handle_t handle, void* buffer, size_t length);
 
+/**
+ * @brief Store data in secure storage at an offset
+ * 
+ * Same as lseco_store, but writes into the region starting at offset.
+ * Bytes outside [offset, offset + length) are left untouched.
+ * 
+ * @param handle Valid handle from lseco_create (must not be NULL)
+ * @param offset Byte offset into the storage
+ * @param data Buffer containing data to store (must not be NULL)
+ * @param length Length of data in bytes (must be > 0, offset + length <= allocated size)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   result := C.lseco_store_at(handle, C.size_t(off), unsafe.Pointer(&p[0]), C.size_t(len(p)))
+ */
+LSECO_API int lseco_store_at(lseco_handle_t handle, size_t offset, const void* data, size_t length);
+
+/**
+ * @brief Retrieve data from secure storage at an offset
+ * 
+ * Same as lseco_retrieve, but reads from the region starting at offset.
+ * 
+ * @param handle Valid handle from lseco_create (must not be NULL)
+ * @param offset Byte offset into the storage
+ * @param buffer Output buffer to receive data (must not be NULL)
+ * @param length Number of bytes to read (must be > 0, offset + length <= allocated size)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   result := C.lseco_retrieve_at(handle, C.size_t(off), unsafe.Pointer(&p[0]), C.size_t(len(p)))
+ */
+LSECO_API int lseco_retrieve_at(lseco_handle_t handle, size_t offset, void* buffer, size_t length);
+
+/**
+ * @brief Receive data from a socket directly into secure storage
+ * 
+ * Calls recv() until length bytes have been written at offset, so the
+ * data never passes through a caller-owned buffer. If the socket is
+ * non-blocking and runs dry, returns LSECO_ERR_IO with errno set to
+ * EAGAIN; wait for readability and call again with the offset advanced
+ * by *received. A peer shutdown returns LSECO_ERR_IO with errno 0.
+ * 
+ * @param handle Valid handle from lseco_create (must not be NULL)
+ * @param offset Byte offset into the storage
+ * @param fd Connected socket descriptor (a SOCKET on Windows)
+ * @param length Number of bytes to receive (must be > 0, offset + length <= allocated size)
+ * @param received Receives the number of bytes written (must not be NULL)
+ * @return LSECO_SUCCESS once length bytes were received, error code on failure
+ * 
+ * Example (Go):
+ *   result, errno := C.lseco_recv_at(handle, C.size_t(off), C.intptr_t(fd), C.size_t(n), &got)
+ */
+LSECO_API int lseco_recv_at(lseco_handle_t handle, size_t offset, intptr_t fd,
+                            size_t length, size_t* received);
+
+/**
+ * @brief Securely zero a range of secure storage
+ * 
+ * Overwrites [offset, offset + length) with zeros using a
+ * compiler-resistant zeroing routine. The handle stays valid.
+ * 
+ * @param handle Valid handle from lseco_create (must not be NULL)
+ * @param offset Byte offset into the storage
+ * @param length Number of bytes to zero (must be > 0, offset + length <= allocated size)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   result := C.lseco_wipe_at(handle, C.size_t(off), C.size_t(n))
+ */
+LSECO_API int lseco_wipe_at(lseco_handle_t handle, size_t offset, size_t length);
+
+/**
+ * @brief Copy a range between two secure storages
+ * 
+ * Copies directly from one protected region to another inside the C layer,
+ * so the data never passes through a caller-visible buffer. dst and src may
+ * be the same handle, in which case the ranges may overlap.
+ * 
+ * @param dst Destination handle (must not be NULL)
+ * @param dst_offset Byte offset into dst
+ * @param src Source handle (must not be NULL)
+ * @param src_offset Byte offset into src
+ * @param length Number of bytes to copy (must be > 0 and fit in both storages)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   clone := C.lseco_create(C.lseco_get_size(handle))
+ *   result := C.lseco_copy_at(clone, 0, handle, 0, C.lseco_get_size(handle))
+ */
+LSECO_API int lseco_copy_at(lseco_handle_t dst, size_t dst_offset,
+                            lseco_handle_t src, size_t src_offset, size_t length);
+
+/**
+ * @brief Compare two secure storages in constant time
+ * 
+ * Compares the first length bytes of both storages without copying either
+ * of them out of the C layer. The running time depends only on length.
+ * 
+ * @param a First handle (must not be NULL)
+ * @param b Second handle (must not be NULL)
+ * @param length Number of bytes to compare (must be > 0 and <= both sizes)
+ * @param equal Receives 1 if equal, 0 otherwise (must not be NULL)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   var equal C.int
+ *   result := C.lseco_compare(a, b, C.size_t(n), &equal)
+ */
+LSECO_API int lseco_compare(lseco_handle_t a, lseco_handle_t b, size_t length, int* equal);
+
+/**
+ * @brief Compare a storage with a caller buffer in constant time
+ * 
+ * Like lseco_compare, but the second operand is ordinary memory, such as
+ * a hash loaded from a database. The secret is not copied out of the
+ * storage, and the running time depends only on length.
+ * 
+ * @param handle Handle to compare (must not be NULL)
+ * @param data Buffer of at least length bytes (must not be NULL)
+ * @param length Number of bytes to compare (must be > 0 and <= size)
+ * @param equal Receives 1 if equal, 0 otherwise (must not be NULL)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   var equal C.int
+ *   result := C.lseco_compare_bytes(handle, unsafe.Pointer(&b[0]), C.size_t(len(b)), &equal)
+ */
+LSECO_API int lseco_compare_bytes(lseco_handle_t handle, const void* data, size_t length, int* equal);
+
+/**
+ * @brief Report which bytes differ between two storages in constant time
+ * 
+ * Writes a 1 to changed[i] for every position among the first length
+ * bytes where a and b differ and a 0 elsewhere, e.g. to confirm that a
+ * key rotation only replaced a trailing nonce. The flags reveal
+ * positions, not values, and the running time depends only on length.
+ * 
+ * @param a First handle (must not be NULL)
+ * @param b Second handle (must not be NULL, may equal a)
+ * @param length Number of bytes to compare (must be > 0 and <= both sizes)
+ * @param changed Buffer of at least length bytes (must not be NULL)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   changed := make([]byte, n)
+ *   result := C.lseco_diff(a, b, C.size_t(n), (*C.uchar)(&changed[0]))
+ */
+LSECO_API int lseco_diff(lseco_handle_t a, lseco_handle_t b, size_t length, unsigned char* changed);
+
+/**
+ * @brief Copy one storage into another in constant time
+ * 
+ * Copies the first length bytes of src to the start of dst with one
+ * volatile load and store per byte and a compiler barrier, so the time
+ * taken depends only on length and never on the content, e.g. for key
+ * material copied during blinding. Use lseco_copy_at when timing does
+ * not matter.
+ * 
+ * @param dst Destination handle (must not be NULL)
+ * @param src Source handle (must not be NULL, may equal dst)
+ * @param length Number of bytes to copy (must be > 0 and <= both sizes)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   result := C.lseco_ct_copy(dst, src, C.size_t(n))
+ */
+LSECO_API int lseco_ct_copy(lseco_handle_t dst, lseco_handle_t src, size_t length);
+
+/**
+ * @brief XOR a mask storage into another storage in place
+ * 
+ * Sets dst[i] ^= mask[i] for the first length bytes without copying
+ * either storage out of the C layer, e.g. to apply a one-time pad.
+ * XOR-ing the same mask again restores the original content.
+ * 
+ * @param dst Handle modified in place (must not be NULL)
+ * @param mask Mask handle (must not be NULL, may equal dst)
+ * @param length Number of bytes to XOR (must be > 0 and <= both sizes)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   result := C.lseco_xor(secret, pad, C.size_t(n))
+ */
+LSECO_API int lseco_xor(lseco_handle_t dst, lseco_handle_t mask, size_t length);
+
+/**
+ * @brief XOR a storage in place with a repeating key, for obfuscation only
+ * 
+ * Sets data[i] ^= key[i % key_len] for the first length bytes inside the
+ * C layer. Applying the same key again restores the original content.
+ * This matches the XOR obfuscation of some legacy protocols and is NOT
+ * encryption; never rely on it to protect a secret.
+ * 
+ * @param handle Handle modified in place (must not be NULL)
+ * @param key Key bytes (must not be NULL)
+ * @param key_len Key length (must be > 0)
+ * @param length Number of bytes to XOR (must be > 0 and <= size)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   result := C.lseco_xor_obfuscate(handle, unsafe.Pointer(&key[0]),
+ *                                   C.size_t(len(key)), C.size_t(n))
+ */
+LSECO_API int lseco_xor_obfuscate(lseco_handle_t handle, const void* key,
+                                  size_t key_len, size_t length);
+
+/**
+ * @brief Encrypt a storage with XChaCha20-Poly1305 under a key storage
+ * 
+ * Encrypts the first length bytes of plaintext under the first 32 bytes
+ * of key, with no additional data, entirely inside the C layer. Neither
+ * secret nor any derived key material leaves locked memory; out receives
+ * the ciphertext followed by the 16-byte tag, compatible with libsodium's
+ * crypto_aead_xchacha20poly1305_ietf_encrypt.
+ * 
+ * @param plaintext Handle to encrypt (must not be NULL)
+ * @param length Number of bytes to encrypt (must be > 0 and <= size)
+ * @param key Key handle of at least LSECO_XCHACHA20_KEY_SIZE bytes (must not be NULL)
+ * @param nonce LSECO_XCHACHA20_NONCE_SIZE bytes, unique per key (must not be NULL)
+ * @param out Buffer of length + LSECO_POLY1305_TAG_SIZE bytes (must not be NULL)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   out := make([]byte, n+C.LSECO_POLY1305_TAG_SIZE)
+ *   result := C.lseco_xchacha20poly1305_seal(secret, C.size_t(n), key,
+ *                                            unsafe.Pointer(&nonce[0]), unsafe.Pointer(&out[0]))
+ */
+LSECO_API int lseco_xchacha20poly1305_seal(lseco_handle_t plaintext, size_t length, lseco_handle_t key,
+                                           const void* nonce, void* out);
+
+/**
+ * @brief Resize secure storage without exposing its content
+ * 
+ * Moves the content into a newly allocated locked region of new_size bytes
+ * (truncating if it is smaller), then zeroes and frees the old region. The
+ * handle remains valid; on failure it is left unchanged.
+ * 
+ * @param handle Valid handle from lseco_create (must not be NULL)
+ * @param new_size New size in bytes (must be > 0)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   result := C.lseco_resize(handle, C.size_t(newSize))
+ */
+LSECO_API int lseco_resize(lseco_handle_t handle, size_t new_size);
+
+/**
+ * @brief Temporarily map secure storage for direct access
+ * 
+ * Grants READWRITE permission on the storage and returns its address so
+ * the caller can process the data in place. Access stays granted until
+ * lseco_release is called; keep that window as short as possible and never
+ * use the address afterwards.
+ * 
+ * @param handle Valid handle from lseco_create (must not be NULL)
+ * @param data Receives the address of the storage (must not be NULL)
+ * @return LSECO_SUCCESS on success, error code on failure
+ * 
+ * Example (Go):
+ *   var ptr unsafe.Pointer
+ *   if C.lseco_acquire(handle, &ptr) == 0 {
+ *     view := unsafe.Slice((*byte)(ptr), size)
+ *     // ... use view ...
+ *     C.lseco_release(handle)
+ *   }
+ */
+LSECO_API int lseco_acquire(lseco_handle_t handle, void** data);
+
+/**
+ * @brief Revoke access granted by lseco_acquire
+ * 
+ * @param handle Valid handle from lseco_create (must not be NULL)
+ * @return LSECO_SUCCESS on success, error code on failure
+ */
+LSECO_API int lseco_release(lseco_handle_t handle);
+
 /**
  * @brief Get the size of allocated secure storage
  * 
@@ -110,6 +461,49 @@ LSECO_API size_t lseco_get_size(lseco_handle_t handle);
  */
 LSECO_API void lseco_destroy(lseco_handle_t handle);
 
+/**
+ * @brief Allocate a locked buffer that the caller can access directly
+ * 
+ * Unlike lseco_create, the returned memory is not access-protected: it is
+ * page-aligned, locked in RAM, excluded from core dumps and zero-filled,
+ * but readable and writable at all times. Use it as scratch space for
+ * plaintext that must not land on a garbage-collected heap.
+ * 
+ * @param size Size in bytes to allocate (must be > 0)
+ * @return Pointer to the buffer on success, NULL on failure
+ * 
+ * Example (Go):
+ *   ptr := C.lseco_buffer_create(256)
+ *   if ptr == nil { panic("failed to create buffer") }
+ *   defer C.lseco_buffer_destroy(ptr, 256)
+ */
+LSECO_API void* lseco_buffer_create(size_t size);
+
+/**
+ * @brief Securely destroy a buffer from lseco_buffer_create
+ * 
+ * Zeros the buffer, unlocks it, and frees it. Safe to call with NULL.
+ * 
+ * @param buffer Buffer to destroy (can be NULL)
+ * @param size Size that was passed to lseco_buffer_create
+ */
+LSECO_API void lseco_buffer_destroy(void* buffer, size_t size);
+
+/**
+ * @brief Zero caller-owned memory without being optimized away
+ * 
+ * Use this to clear plaintext copies (e.g. the source slice passed to
+ * lseco_store) instead of a plain loop or memset, which the compiler may
+ * remove as a dead store.
+ * 
+ * @param ptr Memory to zero (safe to pass NULL)
+ * @param length Number of bytes to zero
+ * 
+ * Example (Go):
+ *   C.lseco_secure_zero(unsafe.Pointer(&b[0]), C.size_t(len(b)))
+ */
+LSECO_API void lseco_secure_zero(void* ptr, size_t length);
+
 /**
  * @brief Get human-readable error message for error code
  * 
@@ -131,6 +525,35 @@ LSECO_API const char* lseco_error_string(int error_code);
  */
 LSECO_API const char* lseco_version(void);
 
+/**
+ * @brief Describe how the library was built
+ * 
+ * Returns "key=value" pairs separated by "; ": compiler, platform, zeroing
+ * primitive, core dump exclusion (dontdump), and compiler flags. Useful to
+ * diagnose mlock or hardening differences on constrained systems.
+ * 
+ * @return Static string (never NULL)
+ * 
+ * Example (Go):
+ *   info := C.GoString(C.lseco_build_info())
+ */
+LSECO_API const char* lseco_build_info(void);
+
+/**
+ * @brief Report whether storages can be placed inside an SGX enclave
+ * 
+ * Enclave-backed storage needs the Intel SGX SDK and a signed enclave
+ * image exposing create/store/retrieve ECALLs. This library does not
+ * ship such an enclave, so for now this always returns 0; bindings
+ * should probe it at runtime rather than assume support.
+ * 
+ * @return 1 if an enclave backend is available, 0 otherwise
+ * 
+ * Example (Go):
+ *   available := C.lseco_sgx_available() != 0
+ */
+LSECO_API int lseco_sgx_available(void);
+
 #ifdef __cplusplus
 }
 #endif
@@ -3,18 +3,26 @@
 #include <stdlib.h>
 #include <string.h>
 
+#include <errno.h>
+
 #ifdef _WIN32
+    #include <winsock2.h>
     #include <windows.h>
 #else
     #include <sys/mman.h>
+    #include <sys/socket.h>
     #include <unistd.h>
 #endif
 
 /* Internal structure */
 struct secure_memory_t {
     void* data;
+    void* base; /* start of the allocation, before the front guard page */
     size_t size;
     size_t page_size;
+    size_t alignment;
+    size_t guard; /* size of each guard page, 0 if there are none */
+    secure_allocator_t allocator; /* alloc is NULL for the built-in allocator */
 #ifdef _WIN32
     HANDLE process_handle;
 #endif
@@ -97,11 +105,124 @@ static void unlock_memory(void* addr, size_t size) {
 }
 
 int secure_memory_create(secure_memory_t** handle, size_t size) {
+    return secure_memory_create_aligned(handle, size, 0);
+}
+
+int secure_memory_create_aligned(secure_memory_t** handle, size_t size, size_t alignment) {
+    return secure_memory_create_with_allocator(handle, size, alignment, NULL);
+}
+
+/* Bytes before the data: a whole alignment unit holding the front guard */
+static size_t front_size(const secure_memory_t* mem) {
+    return mem->guard != 0 ? mem->alignment : 0;
+}
+
+/* Release the allocation itself, without unlocking */
+static void release_allocation(secure_memory_t* mem, size_t total) {
+    if (mem->allocator.alloc != NULL) {
+        mem->allocator.free(mem->base, total, mem->allocator.ctx);
+        return;
+    }
+    
+#ifdef _WIN32
+    VirtualFree(mem->base, 0, MEM_RELEASE);
+#else
+    free(mem->base);
+#endif
+}
+
+/* Allocate and lock a region, with the custom allocator if there is one */
+static int alloc_region(secure_memory_t* mem, size_t aligned_size) {
+    size_t front = front_size(mem);
+    size_t total = front + aligned_size + mem->guard;
+    
+    if (mem->allocator.alloc != NULL) {
+        mem->base = mem->allocator.alloc(total, mem->alignment, mem->allocator.ctx);
+        if (mem->base == NULL) {
+            return SECURE_ERR_ALLOC_FAILED;
+        }
+        /* Protection works on whole pages */
+        if ((uintptr_t)mem->base % mem->alignment != 0) {
+            release_allocation(mem, total);
+            return SECURE_ERR_ALLOC_FAILED;
+        }
+    } else {
+#ifdef _WIN32
+        /* VirtualAlloc only guarantees the 64 KiB allocation granularity */
+        if (mem->alignment > 65536) {
+            return SECURE_ERR_INVALID_SIZE;
+        }
+        mem->process_handle = GetCurrentProcess();
+        mem->base = VirtualAlloc(NULL, total, MEM_COMMIT | MEM_RESERVE, PAGE_READWRITE);
+        if (mem->base == NULL) {
+            return SECURE_ERR_ALLOC_FAILED;
+        }
+#else
+        if (posix_memalign(&mem->base, mem->alignment, total) != 0) {
+            return SECURE_ERR_ALLOC_FAILED;
+        }
+#endif
+    }
+    mem->data = (unsigned char*)mem->base + front;
+    
+    /* Lock memory in RAM; guard pages are never touched, so are not locked */
+    if (mem->allocator.alloc == NULL) {
+        int lock_result = lock_memory(mem->data, aligned_size);
+        if (lock_result != SECURE_SUCCESS) {
+            release_allocation(mem, total);
+            return lock_result;
+        }
+    }
+    
+    /* Revoke all access to the guard pages for the region's lifetime */
+    if (mem->guard != 0) {
+        unsigned char* back = (unsigned char*)mem->data + aligned_size;
+        int result = set_memory_protection((unsigned char*)mem->data - mem->guard, mem->guard, 0);
+        if (result == SECURE_SUCCESS) {
+            result = set_memory_protection(back, mem->guard, 0);
+            if (result != SECURE_SUCCESS) {
+                set_memory_protection((unsigned char*)mem->data - mem->guard, mem->guard, 1);
+            }
+        }
+        if (result != SECURE_SUCCESS) {
+            if (mem->allocator.alloc == NULL) {
+                unlock_memory(mem->data, aligned_size);
+            }
+            release_allocation(mem, total);
+            return result;
+        }
+    }
+    return SECURE_SUCCESS;
+}
+
+/* Unlock and free a region from alloc_region */
+static void free_region(secure_memory_t* mem, size_t aligned_size) {
+    /* The allocator may reuse the guard pages, so make them accessible again */
+    if (mem->guard != 0) {
+        set_memory_protection((unsigned char*)mem->data - mem->guard, mem->guard, 1);
+        set_memory_protection((unsigned char*)mem->data + aligned_size, mem->guard, 1);
+    }
+    if (mem->allocator.alloc == NULL) {
+        unlock_memory(mem->data, aligned_size);
+    }
+    release_allocation(mem, front_size(mem) + aligned_size + mem->guard);
+}
+
+int secure_memory_create_with_allocator(secure_memory_t** handle, size_t size, size_t alignment,
+                                        const secure_allocator_t* allocator) {
+    return secure_memory_create_guarded(handle, size, alignment, allocator, 0);
+}
+
+int secure_memory_create_guarded(secure_memory_t** handle, size_t size, size_t alignment,
+                                 const secure_allocator_t* allocator, int guard_pages) {
     /* Input validation */
     if (handle == NULL) {
         return SECURE_ERR_NULL_PTR;
     }
-    if (size == 0) {
+    if (allocator != NULL && (allocator->alloc == NULL || allocator->free == NULL)) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (size == 0 || (alignment & (alignment - 1)) != 0) {
         return SECURE_ERR_INVALID_SIZE;
     }
     
@@ -113,46 +234,31 @@ int secure_memory_create(secure_memory_t** handle, size_t size) {
     
     mem->size = size;
     mem->page_size = get_page_size();
+    if (alignment < mem->page_size) {
+        alignment = mem->page_size;
+    }
+    mem->alignment = alignment;
+    mem->guard = guard_pages ? mem->page_size : 0;
+    if (allocator != NULL) {
+        mem->allocator = *allocator;
+    } else {
+        memset(&mem->allocator, 0, sizeof(mem->allocator));
+    }
     
     /* Round up size to page boundary */
     size_t aligned_size = ((size + mem->page_size - 1) / mem->page_size) * mem->page_size;
     
-    /* Allocate page-aligned memory */
-#ifdef _WIN32
-    mem->process_handle = GetCurrentProcess();
-    mem->data = VirtualAlloc(NULL, aligned_size, MEM_COMMIT | MEM_RESERVE, PAGE_READWRITE);
-    if (mem->data == NULL) {
-        free(mem);
-        return SECURE_ERR_ALLOC_FAILED;
-    }
-#else
-    if (posix_memalign(&mem->data, mem->page_size, aligned_size) != 0) {
-        free(mem);
-        return SECURE_ERR_ALLOC_FAILED;
-    }
-#endif
-    
-    /* Lock memory in RAM */
-    int lock_result = lock_memory(mem->data, aligned_size);
-    if (lock_result != SECURE_SUCCESS) {
-#ifdef _WIN32
-        VirtualFree(mem->data, 0, MEM_RELEASE);
-#else
-        free(mem->data);
-#endif
+    /* Allocate page-aligned, locked memory */
+    int alloc_result = alloc_region(mem, aligned_size);
+    if (alloc_result != SECURE_SUCCESS) {
         free(mem);
-        return lock_result;
+        return alloc_result;
     }
     
     /* Set memory to NOACCESS */
     int protect_result = set_memory_protection(mem->data, aligned_size, 0);
     if (protect_result != SECURE_SUCCESS) {
-        unlock_memory(mem->data, aligned_size);
-#ifdef _WIN32
-        VirtualFree(mem->data, 0, MEM_RELEASE);
-#else
-        free(mem->data);
-#endif
+        free_region(mem, aligned_size);
         free(mem);
         return protect_result;
     }
@@ -162,11 +268,19 @@ int secure_memory_create(secure_memory_t** handle, size_t size) {
 }
 
 int secure_memory_write(secure_memory_t* handle, const void* data, size_t length) {
+    return secure_memory_write_at(handle, 0, data, length);
+}
+
+int secure_memory_read(const secure_memory_t* handle, void* buffer, size_t length) {
+    return secure_memory_read_at(handle, 0, buffer, length);
+}
+
+int secure_memory_write_at(secure_memory_t* handle, size_t offset, const void* data, size_t length) {
     /* Input validation */
     if (handle == NULL || data == NULL) {
         return SECURE_ERR_NULL_PTR;
     }
-    if (length == 0 || length > handle->size) {
+    if (length == 0 || offset > handle->size || length > handle->size - offset) {
         return SECURE_ERR_INVALID_SIZE;
     }
     
@@ -179,7 +293,7 @@ int secure_memory_write(secure_memory_t* handle, const void* data, size_t length
     }
     
     /* Copy data */
-    memcpy(handle->data, data, length);
+    memcpy((unsigned char*)handle->data + offset, data, length);
     
     /* Revoke access */
     result = set_memory_protection(handle->data, aligned_size, 0);
@@ -190,12 +304,12 @@ int secure_memory_write(secure_memory_t* handle, const void* data, size_t length
     return SECURE_SUCCESS;
 }
 
-int secure_memory_read(const secure_memory_t* handle, void* buffer, size_t length) {
+int secure_memory_read_at(const secure_memory_t* handle, size_t offset, void* buffer, size_t length) {
     /* Input validation */
     if (handle == NULL || buffer == NULL) {
         return SECURE_ERR_NULL_PTR;
     }
-    if (length == 0 || length > handle->size) {
+    if (length == 0 || offset > handle->size || length > handle->size - offset) {
         return SECURE_ERR_INVALID_SIZE;
     }
     
@@ -209,7 +323,7 @@ int secure_memory_read(const secure_memory_t* handle, void* buffer, size_t lengt
     }
     
     /* Copy data out */
-    memcpy(buffer, handle->data, length);
+    memcpy(buffer, (const unsigned char*)handle->data + offset, length);
     
     /* Revoke access */
     result = set_memory_protection(mutable_handle->data, aligned_size, 0);
@@ -220,6 +334,647 @@ int secure_memory_read(const secure_memory_t* handle, void* buffer, size_t lengt
     return SECURE_SUCCESS;
 }
 
+/* Receive once from fd; returns bytes received, 0 on shutdown, -1 with errno set */
+static long recv_once(intptr_t fd, void* buffer, size_t length) {
+#ifdef _WIN32
+    int n = recv((SOCKET)fd, (char*)buffer, length > INT32_MAX ? INT32_MAX : (int)length, 0);
+    if (n == SOCKET_ERROR) {
+        int wsa_error = WSAGetLastError();
+        errno = wsa_error == WSAEWOULDBLOCK ? EAGAIN : wsa_error == WSAEINTR ? EINTR : EIO;
+        return -1;
+    }
+    return n;
+#else
+    return (long)recv((int)fd, buffer, length, 0);
+#endif
+}
+
+int secure_memory_recv_at(secure_memory_t* handle, size_t offset, intptr_t fd,
+                          size_t length, size_t* received) {
+    /* Input validation */
+    if (handle == NULL || received == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (length == 0 || offset > handle->size || length > handle->size - offset) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    *received = 0;
+    
+    size_t aligned_size = ((handle->size + handle->page_size - 1) / handle->page_size) * handle->page_size;
+    
+    /* Grant READWRITE permission */
+    int result = set_memory_protection(handle->data, aligned_size, 1);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* Receive until the range is filled or the socket stops us */
+    int status = SECURE_SUCCESS;
+    int saved_errno = 0;
+    unsigned char* dst = (unsigned char*)handle->data + offset;
+    while (*received < length) {
+        long n = recv_once(fd, dst + *received, length - *received);
+        if (n > 0) {
+            *received += (size_t)n;
+            continue;
+        }
+        if (n < 0 && errno == EINTR) {
+            continue;
+        }
+        saved_errno = n == 0 ? 0 : errno;
+        status = SECURE_ERR_IO;
+        break;
+    }
+    
+    /* Revoke access */
+    result = set_memory_protection(handle->data, aligned_size, 0);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    errno = saved_errno;
+    return status;
+}
+
+int secure_memory_wipe_at(secure_memory_t* handle, size_t offset, size_t length) {
+    /* Input validation */
+    if (handle == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (length == 0 || offset > handle->size || length > handle->size - offset) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    size_t aligned_size = ((handle->size + handle->page_size - 1) / handle->page_size) * handle->page_size;
+    
+    /* Grant READWRITE permission */
+    int result = set_memory_protection(handle->data, aligned_size, 1);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* Securely zero the range */
+    secure_zero((unsigned char*)handle->data + offset, length);
+    
+    /* Revoke access */
+    result = set_memory_protection(handle->data, aligned_size, 0);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    return SECURE_SUCCESS;
+}
+
+/* Grant READWRITE permission on two regions, which may be the same */
+static int grant_pair_access(secure_memory_t* a, secure_memory_t* b) {
+    size_t a_aligned = ((a->size + a->page_size - 1) / a->page_size) * a->page_size;
+    int result = set_memory_protection(a->data, a_aligned, 1);
+    if (result != SECURE_SUCCESS || a == b) {
+        return result;
+    }
+    
+    size_t b_aligned = ((b->size + b->page_size - 1) / b->page_size) * b->page_size;
+    result = set_memory_protection(b->data, b_aligned, 1);
+    if (result != SECURE_SUCCESS) {
+        set_memory_protection(a->data, a_aligned, 0);
+    }
+    return result;
+}
+
+/* Revoke access on two regions granted by grant_pair_access */
+static int revoke_pair_access(secure_memory_t* a, secure_memory_t* b) {
+    int b_result = SECURE_SUCCESS;
+    if (a != b) {
+        size_t b_aligned = ((b->size + b->page_size - 1) / b->page_size) * b->page_size;
+        b_result = set_memory_protection(b->data, b_aligned, 0);
+    }
+    
+    size_t a_aligned = ((a->size + a->page_size - 1) / a->page_size) * a->page_size;
+    int result = set_memory_protection(a->data, a_aligned, 0);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    return b_result;
+}
+
+int secure_memory_copy_at(secure_memory_t* dst, size_t dst_offset,
+                          const secure_memory_t* src, size_t src_offset, size_t length) {
+    /* Input validation */
+    if (dst == NULL || src == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (length == 0 ||
+        dst_offset > dst->size || length > dst->size - dst_offset ||
+        src_offset > src->size || length > src->size - src_offset) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    /* Grant READWRITE permission on both regions */
+    secure_memory_t* mutable_src = (secure_memory_t*)src;
+    int result = grant_pair_access(dst, mutable_src);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* Copy data (memmove: ranges may overlap within one handle) */
+    memmove((unsigned char*)dst->data + dst_offset,
+            (const unsigned char*)src->data + src_offset, length);
+    
+    /* Revoke access */
+    return revoke_pair_access(dst, mutable_src);
+}
+
+int secure_memory_diff(const secure_memory_t* a, const secure_memory_t* b,
+                       size_t length, unsigned char* changed) {
+    /* Input validation */
+    if (a == NULL || b == NULL || changed == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (length == 0 || length > a->size || length > b->size) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    /* Grant access on both regions */
+    secure_memory_t* mutable_a = (secure_memory_t*)a;
+    secure_memory_t* mutable_b = (secure_memory_t*)b;
+    int result = grant_pair_access(mutable_a, mutable_b);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* Constant-time per-byte flag: (d + 255) >> 8 is 1 iff d != 0 */
+    const volatile unsigned char* pa = (const volatile unsigned char*)a->data;
+    const volatile unsigned char* pb = (const volatile unsigned char*)b->data;
+    volatile unsigned char* pc = (volatile unsigned char*)changed;
+    for (size_t i = 0; i < length; i++) {
+        unsigned int d = (unsigned int)(pa[i] ^ pb[i]);
+        pc[i] = (unsigned char)((d + 0xff) >> 8);
+    }
+    
+    /* Revoke access */
+    return revoke_pair_access(mutable_a, mutable_b);
+}
+
+int secure_memory_ct_copy(secure_memory_t* dst, const secure_memory_t* src, size_t length) {
+    /* Input validation */
+    if (dst == NULL || src == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (length == 0 || length > dst->size || length > src->size) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    /* Grant READWRITE permission on both regions */
+    secure_memory_t* mutable_src = (secure_memory_t*)src;
+    int result = grant_pair_access(dst, mutable_src);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* Constant-time copy: one volatile load and store per byte */
+    volatile unsigned char* pd = (volatile unsigned char*)dst->data;
+    const volatile unsigned char* ps = (const volatile unsigned char*)src->data;
+    for (size_t i = 0; i < length; i++) {
+        pd[i] = ps[i];
+    }
+#if defined(__GNUC__) || defined(__clang__)
+    __asm__ __volatile__("" ::: "memory");
+#endif
+    
+    /* Revoke access */
+    return revoke_pair_access(dst, mutable_src);
+}
+
+int secure_memory_compare(const secure_memory_t* a, const secure_memory_t* b,
+                          size_t length, int* equal) {
+    /* Input validation */
+    if (a == NULL || b == NULL || equal == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (length == 0 || length > a->size || length > b->size) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    /* Grant access on both regions */
+    secure_memory_t* mutable_a = (secure_memory_t*)a;
+    secure_memory_t* mutable_b = (secure_memory_t*)b;
+    int result = grant_pair_access(mutable_a, mutable_b);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* Constant-time comparison: always touches every byte */
+    const volatile unsigned char* pa = (const volatile unsigned char*)a->data;
+    const volatile unsigned char* pb = (const volatile unsigned char*)b->data;
+    unsigned char diff = 0;
+    for (size_t i = 0; i < length; i++) {
+        diff |= pa[i] ^ pb[i];
+    }
+    
+    /* Revoke access */
+    result = revoke_pair_access(mutable_a, mutable_b);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    *equal = (diff == 0);
+    return SECURE_SUCCESS;
+}
+
+int secure_memory_compare_bytes(const secure_memory_t* handle, const void* data,
+                                size_t length, int* equal) {
+    /* Input validation */
+    if (handle == NULL || data == NULL || equal == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (length == 0 || length > handle->size) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    size_t aligned_size = ((handle->size + handle->page_size - 1) / handle->page_size) * handle->page_size;
+    
+    /* Grant READWRITE permission */
+    int result = set_memory_protection(handle->data, aligned_size, 1);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* Constant-time comparison: always touches every byte */
+    const volatile unsigned char* pa = (const volatile unsigned char*)handle->data;
+    const volatile unsigned char* pb = (const volatile unsigned char*)data;
+    unsigned char diff = 0;
+    for (size_t i = 0; i < length; i++) {
+        diff |= pa[i] ^ pb[i];
+    }
+    
+    /* Revoke access */
+    result = set_memory_protection(handle->data, aligned_size, 0);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    *equal = (diff == 0);
+    return SECURE_SUCCESS;
+}
+
+int secure_memory_xor(secure_memory_t* dst, const secure_memory_t* src, size_t length) {
+    /* Input validation */
+    if (dst == NULL || src == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (length == 0 || length > dst->size || length > src->size) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    /* Grant READWRITE permission on both regions */
+    secure_memory_t* mutable_src = (secure_memory_t*)src;
+    int result = grant_pair_access(dst, mutable_src);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* XOR in place, byte by byte */
+    unsigned char* pd = (unsigned char*)dst->data;
+    const unsigned char* ps = (const unsigned char*)src->data;
+    for (size_t i = 0; i < length; i++) {
+        pd[i] ^= ps[i];
+    }
+    
+    /* Revoke access */
+    return revoke_pair_access(dst, mutable_src);
+}
+
+int secure_memory_xor_obfuscate(secure_memory_t* handle, const void* key,
+                                size_t key_len, size_t length) {
+    /* Input validation */
+    if (handle == NULL || key == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (key_len == 0 || length == 0 || length > handle->size) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    size_t aligned_size = ((handle->size + handle->page_size - 1) / handle->page_size) * handle->page_size;
+    
+    /* Grant READWRITE permission */
+    int result = set_memory_protection(handle->data, aligned_size, 1);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* XOR in place, cycling through the key */
+    unsigned char* pd = (unsigned char*)handle->data;
+    const unsigned char* pk = (const unsigned char*)key;
+    for (size_t i = 0, k = 0; i < length; i++) {
+        pd[i] ^= pk[k];
+        if (++k == key_len) {
+            k = 0;
+        }
+    }
+    
+    /* Revoke access */
+    return set_memory_protection(handle->data, aligned_size, 0);
+}
+
+/* ChaCha20 (RFC 8439) and HChaCha20 helpers for XChaCha20-Poly1305 */
+#define CHACHA_ROTL(v, n) (((v) << (n)) | ((v) >> (32 - (n))))
+#define CHACHA_QR(a, b, c, d) \
+    a += b; d ^= a; d = CHACHA_ROTL(d, 16); \
+    c += d; b ^= c; b = CHACHA_ROTL(b, 12); \
+    a += b; d ^= a; d = CHACHA_ROTL(d, 8); \
+    c += d; b ^= c; b = CHACHA_ROTL(b, 7)
+
+static uint32_t load32_le(const unsigned char* p) {
+    return (uint32_t)p[0] | ((uint32_t)p[1] << 8) | ((uint32_t)p[2] << 16) | ((uint32_t)p[3] << 24);
+}
+
+static void store32_le(unsigned char* p, uint32_t v) {
+    p[0] = (unsigned char)v;
+    p[1] = (unsigned char)(v >> 8);
+    p[2] = (unsigned char)(v >> 16);
+    p[3] = (unsigned char)(v >> 24);
+}
+
+/* Build the initial state from a 32-byte key and 16 bytes of counter/nonce */
+static void chacha_init(uint32_t state[16], const unsigned char key[32], const unsigned char input[16]) {
+    state[0] = 0x61707865;
+    state[1] = 0x3320646e;
+    state[2] = 0x79622d32;
+    state[3] = 0x6b206574;
+    for (int i = 0; i < 8; i++) {
+        state[4 + i] = load32_le(key + 4 * i);
+    }
+    for (int i = 0; i < 4; i++) {
+        state[12 + i] = load32_le(input + 4 * i);
+    }
+}
+
+static void chacha_rounds(uint32_t x[16]) {
+    for (int i = 0; i < 10; i++) {
+        CHACHA_QR(x[0], x[4], x[8], x[12]);
+        CHACHA_QR(x[1], x[5], x[9], x[13]);
+        CHACHA_QR(x[2], x[6], x[10], x[14]);
+        CHACHA_QR(x[3], x[7], x[11], x[15]);
+        CHACHA_QR(x[0], x[5], x[10], x[15]);
+        CHACHA_QR(x[1], x[6], x[11], x[12]);
+        CHACHA_QR(x[2], x[7], x[8], x[13]);
+        CHACHA_QR(x[3], x[4], x[9], x[14]);
+    }
+}
+
+/* Produce one 64-byte keystream block and advance the block counter */
+static void chacha_block(uint32_t state[16], unsigned char out[64]) {
+    uint32_t x[16];
+    memcpy(x, state, sizeof(x));
+    chacha_rounds(x);
+    for (int i = 0; i < 16; i++) {
+        store32_le(out + 4 * i, x[i] + state[i]);
+    }
+    state[12]++;
+    secure_zero(x, sizeof(x));
+}
+
+/* Derive the XChaCha20 subkey from the key and the first 16 nonce bytes */
+static void hchacha20(unsigned char subkey[32], const unsigned char key[32], const unsigned char nonce[16]) {
+    uint32_t x[16];
+    chacha_init(x, key, nonce);
+    chacha_rounds(x);
+    for (int i = 0; i < 4; i++) {
+        store32_le(subkey + 4 * i, x[i]);
+        store32_le(subkey + 16 + 4 * i, x[12 + i]);
+    }
+    secure_zero(x, sizeof(x));
+}
+
+/* Poly1305 (RFC 8439) with 26-bit limbs */
+typedef struct {
+    uint32_t r[5];
+    uint32_t h[5];
+    uint32_t pad[4];
+} poly1305_t;
+
+static void poly1305_init(poly1305_t* st, const unsigned char key[32]) {
+    st->r[0] = load32_le(key + 0) & 0x3ffffff;
+    st->r[1] = (load32_le(key + 3) >> 2) & 0x3ffff03;
+    st->r[2] = (load32_le(key + 6) >> 4) & 0x3ffc0ff;
+    st->r[3] = (load32_le(key + 9) >> 6) & 0x3f03fff;
+    st->r[4] = (load32_le(key + 12) >> 8) & 0x00fffff;
+    for (int i = 0; i < 5; i++) {
+        st->h[i] = 0;
+    }
+    for (int i = 0; i < 4; i++) {
+        st->pad[i] = load32_le(key + 16 + 4 * i);
+    }
+}
+
+/* Absorb one full 16-byte block; the AEAD pads every block to 16 bytes */
+static void poly1305_block(poly1305_t* st, const unsigned char m[16]) {
+    const uint32_t r0 = st->r[0], r1 = st->r[1], r2 = st->r[2], r3 = st->r[3], r4 = st->r[4];
+    const uint32_t s1 = r1 * 5, s2 = r2 * 5, s3 = r3 * 5, s4 = r4 * 5;
+    uint32_t h0 = st->h[0], h1 = st->h[1], h2 = st->h[2], h3 = st->h[3], h4 = st->h[4];
+    
+    h0 += load32_le(m + 0) & 0x3ffffff;
+    h1 += (load32_le(m + 3) >> 2) & 0x3ffffff;
+    h2 += (load32_le(m + 6) >> 4) & 0x3ffffff;
+    h3 += (load32_le(m + 9) >> 6) & 0x3ffffff;
+    h4 += (load32_le(m + 12) >> 8) | (1 << 24);
+    
+    uint64_t d0 = (uint64_t)h0 * r0 + (uint64_t)h1 * s4 + (uint64_t)h2 * s3 + (uint64_t)h3 * s2 + (uint64_t)h4 * s1;
+    uint64_t d1 = (uint64_t)h0 * r1 + (uint64_t)h1 * r0 + (uint64_t)h2 * s4 + (uint64_t)h3 * s3 + (uint64_t)h4 * s2;
+    uint64_t d2 = (uint64_t)h0 * r2 + (uint64_t)h1 * r1 + (uint64_t)h2 * r0 + (uint64_t)h3 * s4 + (uint64_t)h4 * s3;
+    uint64_t d3 = (uint64_t)h0 * r3 + (uint64_t)h1 * r2 + (uint64_t)h2 * r1 + (uint64_t)h3 * r0 + (uint64_t)h4 * s4;
+    uint64_t d4 = (uint64_t)h0 * r4 + (uint64_t)h1 * r3 + (uint64_t)h2 * r2 + (uint64_t)h3 * r1 + (uint64_t)h4 * r0;
+    
+    uint32_t c;
+    c = (uint32_t)(d0 >> 26); h0 = (uint32_t)d0 & 0x3ffffff;
+    d1 += c; c = (uint32_t)(d1 >> 26); h1 = (uint32_t)d1 & 0x3ffffff;
+    d2 += c; c = (uint32_t)(d2 >> 26); h2 = (uint32_t)d2 & 0x3ffffff;
+    d3 += c; c = (uint32_t)(d3 >> 26); h3 = (uint32_t)d3 & 0x3ffffff;
+    d4 += c; c = (uint32_t)(d4 >> 26); h4 = (uint32_t)d4 & 0x3ffffff;
+    h0 += c * 5; c = h0 >> 26; h0 &= 0x3ffffff;
+    h1 += c;
+    
+    st->h[0] = h0; st->h[1] = h1; st->h[2] = h2; st->h[3] = h3; st->h[4] = h4;
+}
+
+/* Fully reduce the accumulator, add the pad, and write the tag */
+static void poly1305_finish(poly1305_t* st, unsigned char tag[16]) {
+    uint32_t h0 = st->h[0], h1 = st->h[1], h2 = st->h[2], h3 = st->h[3], h4 = st->h[4];
+    uint32_t c;
+    c = h1 >> 26; h1 &= 0x3ffffff;
+    h2 += c; c = h2 >> 26; h2 &= 0x3ffffff;
+    h3 += c; c = h3 >> 26; h3 &= 0x3ffffff;
+    h4 += c; c = h4 >> 26; h4 &= 0x3ffffff;
+    h0 += c * 5; c = h0 >> 26; h0 &= 0x3ffffff;
+    h1 += c;
+    
+    /* Compute h - p and select it without branching if h >= p */
+    uint32_t g0 = h0 + 5; c = g0 >> 26; g0 &= 0x3ffffff;
+    uint32_t g1 = h1 + c; c = g1 >> 26; g1 &= 0x3ffffff;
+    uint32_t g2 = h2 + c; c = g2 >> 26; g2 &= 0x3ffffff;
+    uint32_t g3 = h3 + c; c = g3 >> 26; g3 &= 0x3ffffff;
+    uint32_t g4 = h4 + c - (1 << 26);
+    uint32_t mask = (g4 >> 31) - 1;
+    g0 &= mask; g1 &= mask; g2 &= mask; g3 &= mask; g4 &= mask;
+    mask = ~mask;
+    h0 = (h0 & mask) | g0;
+    h1 = (h1 & mask) | g1;
+    h2 = (h2 & mask) | g2;
+    h3 = (h3 & mask) | g3;
+    h4 = (h4 & mask) | g4;
+    
+    h0 = h0 | (h1 << 26);
+    h1 = (h1 >> 6) | (h2 << 20);
+    h2 = (h2 >> 12) | (h3 << 14);
+    h3 = (h3 >> 18) | (h4 << 8);
+    
+    uint64_t f;
+    f = (uint64_t)h0 + st->pad[0]; store32_le(tag + 0, (uint32_t)f);
+    f = (uint64_t)h1 + st->pad[1] + (f >> 32); store32_le(tag + 4, (uint32_t)f);
+    f = (uint64_t)h2 + st->pad[2] + (f >> 32); store32_le(tag + 8, (uint32_t)f);
+    f = (uint64_t)h3 + st->pad[3] + (f >> 32); store32_le(tag + 12, (uint32_t)f);
+}
+
+int secure_memory_xchacha20poly1305_seal(const secure_memory_t* plaintext, size_t length,
+                                         const secure_memory_t* key, const void* nonce, void* out) {
+    /* Input validation */
+    if (plaintext == NULL || key == NULL || nonce == NULL || out == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (length == 0 || length > plaintext->size || key->size < SECURE_XCHACHA20_KEY_SIZE) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    /* Grant access on both regions */
+    secure_memory_t* mutable_plaintext = (secure_memory_t*)plaintext;
+    secure_memory_t* mutable_key = (secure_memory_t*)key;
+    int result = grant_pair_access(mutable_plaintext, mutable_key);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* Subkey and 12-byte nonce for ChaCha20: 4 zero bytes, then nonce[16:24] */
+    const unsigned char* n = (const unsigned char*)nonce;
+    unsigned char subkey[32];
+    unsigned char input[16] = {0};
+    hchacha20(subkey, (const unsigned char*)key->data, n);
+    memcpy(input + 8, n + 16, 8);
+    
+    uint32_t state[16];
+    unsigned char block[64];
+    poly1305_t mac;
+    chacha_init(state, subkey, input);
+    secure_zero(subkey, sizeof(subkey));
+    
+    /* Block 0 keys Poly1305; encryption starts at block 1 */
+    chacha_block(state, block);
+    poly1305_init(&mac, block);
+    
+    const unsigned char* pt = (const unsigned char*)plaintext->data;
+    unsigned char* ct = (unsigned char*)out;
+    for (size_t off = 0; off < length; off += 64) {
+        size_t n_bytes = length - off < 64 ? length - off : 64;
+        chacha_block(state, block);
+        for (size_t i = 0; i < n_bytes; i++) {
+            ct[off + i] = pt[off + i] ^ block[i];
+        }
+    }
+    
+    /* Tag over ciphertext padded to 16 bytes, then both lengths (no AAD) */
+    for (size_t off = 0; off + 16 <= length; off += 16) {
+        poly1305_block(&mac, ct + off);
+    }
+    memset(block, 0, 16);
+    if (length % 16 != 0) {
+        memcpy(block, ct + length - length % 16, length % 16);
+        poly1305_block(&mac, block);
+        memset(block, 0, 16);
+    }
+    uint64_t ct_len = (uint64_t)length;
+    store32_le(block + 8, (uint32_t)ct_len);
+    store32_le(block + 12, (uint32_t)(ct_len >> 32));
+    poly1305_block(&mac, block);
+    poly1305_finish(&mac, ct + length);
+    
+    secure_zero(state, sizeof(state));
+    secure_zero(block, sizeof(block));
+    secure_zero(&mac, sizeof(mac));
+    
+    /* Revoke access */
+    return revoke_pair_access(mutable_plaintext, mutable_key);
+}
+
+int secure_memory_resize(secure_memory_t* handle, size_t new_size) {
+    /* Input validation */
+    if (handle == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (new_size == 0) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    /* Allocate the new region from the same allocator, with the same guards */
+    secure_memory_t* fresh = NULL;
+    int result = secure_memory_create_guarded(&fresh, new_size, handle->alignment,
+                                              handle->allocator.alloc != NULL ? &handle->allocator : NULL,
+                                              handle->guard != 0);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    /* Copy existing content region-to-region */
+    size_t keep = handle->size < new_size ? handle->size : new_size;
+    result = secure_memory_copy_at(fresh, 0, handle, 0, keep);
+    if (result != SECURE_SUCCESS) {
+        secure_memory_destroy(&fresh);
+        return result;
+    }
+    
+    /* Swap regions, then zero and free the old one */
+    void* old_data = handle->data;
+    void* old_base = handle->base;
+    size_t old_size = handle->size;
+    handle->data = fresh->data;
+    handle->base = fresh->base;
+    handle->size = fresh->size;
+    fresh->data = old_data;
+    fresh->base = old_base;
+    fresh->size = old_size;
+    secure_memory_destroy(&fresh);
+    
+    return SECURE_SUCCESS;
+}
+
+int secure_memory_acquire(secure_memory_t* handle, void** data) {
+    /* Input validation */
+    if (handle == NULL || data == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    
+    size_t aligned_size = ((handle->size + handle->page_size - 1) / handle->page_size) * handle->page_size;
+    
+    /* Grant READWRITE permission */
+    int result = set_memory_protection(handle->data, aligned_size, 1);
+    if (result != SECURE_SUCCESS) {
+        return result;
+    }
+    
+    *data = handle->data;
+    return SECURE_SUCCESS;
+}
+
+int secure_memory_release(secure_memory_t* handle) {
+    /* Input validation */
+    if (handle == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    
+    size_t aligned_size = ((handle->size + handle->page_size - 1) / handle->page_size) * handle->page_size;
+    
+    /* Revoke access */
+    return set_memory_protection(handle->data, aligned_size, 0);
+}
+
 void secure_memory_destroy(secure_memory_t** handle) {
     if (handle == NULL || *handle == NULL) {
         return;
@@ -234,15 +989,8 @@ void secure_memory_destroy(secure_memory_t** handle) {
     /* Securely zero memory */
     secure_zero(mem->data, aligned_size);
     
-    /* Unlock memory */
-    unlock_memory(mem->data, aligned_size);
-    
-    /* Free memory */
-#ifdef _WIN32
-    VirtualFree(mem->data, 0, MEM_RELEASE);
-#else
-    free(mem->data);
-#endif
+    /* Unlock and free memory */
+    free_region(mem, aligned_size);
     
     /* Free handle */
     free(mem);
@@ -255,3 +1003,125 @@ size_t secure_memory_get_size(const secure_memory_t* handle) {
     }
     return handle->size;
 }
+
+int secure_buffer_alloc(void** buffer, size_t size) {
+    /* Input validation */
+    if (buffer == NULL) {
+        return SECURE_ERR_NULL_PTR;
+    }
+    if (size == 0) {
+        return SECURE_ERR_INVALID_SIZE;
+    }
+    
+    size_t page_size = get_page_size();
+    size_t aligned_size = ((size + page_size - 1) / page_size) * page_size;
+    void* data = NULL;
+    
+    /* Allocate page-aligned memory */
+#ifdef _WIN32
+    data = VirtualAlloc(NULL, aligned_size, MEM_COMMIT | MEM_RESERVE, PAGE_READWRITE);
+    if (data == NULL) {
+        return SECURE_ERR_ALLOC_FAILED;
+    }
+#else
+    if (posix_memalign(&data, page_size, aligned_size) != 0) {
+        return SECURE_ERR_ALLOC_FAILED;
+    }
+#endif
+    
+    /* Lock memory in RAM */
+    int lock_result = lock_memory(data, aligned_size);
+    if (lock_result != SECURE_SUCCESS) {
+#ifdef _WIN32
+        VirtualFree(data, 0, MEM_RELEASE);
+#else
+        free(data);
+#endif
+        return lock_result;
+    }
+    
+    secure_zero(data, aligned_size);
+    
+    *buffer = data;
+    return SECURE_SUCCESS;
+}
+
+#ifndef LSECO_BUILD_FLAGS
+    #define LSECO_BUILD_FLAGS "unknown"
+#endif
+
+#if defined(__clang__)
+    #define BUILD_COMPILER "clang " __clang_version__
+#elif defined(__GNUC__)
+    #define BUILD_COMPILER "gcc " __VERSION__
+#elif defined(_MSC_VER)
+    #define BUILD_COMPILER "msvc"
+#else
+    #define BUILD_COMPILER "unknown"
+#endif
+
+#if defined(_WIN32)
+    #define BUILD_PLATFORM "windows"
+#elif defined(__APPLE__)
+    #define BUILD_PLATFORM "darwin"
+#elif defined(__linux__)
+    #define BUILD_PLATFORM "linux"
+#else
+    #define BUILD_PLATFORM "posix"
+#endif
+
+/* Must mirror the selection in secure_zero */
+#if defined(_WIN32)
+    #define BUILD_ZERO "SecureZeroMemory"
+#elif defined(__STDC_LIB_EXT1__)
+    #define BUILD_ZERO "memset_s"
+#elif defined(__GLIBC__) && __GLIBC__ >= 2 && __GLIBC_MINOR__ >= 25
+    #define BUILD_ZERO "explicit_bzero"
+#else
+    #define BUILD_ZERO "volatile"
+#endif
+
+/* Must mirror the selection in lock_memory */
+#if defined(__linux__) && defined(MADV_DONTDUMP)
+    #define BUILD_DONTDUMP "yes"
+#else
+    #define BUILD_DONTDUMP "no"
+#endif
+
+const char* secure_memory_build_info(void) {
+    return "compiler=" BUILD_COMPILER
+           "; platform=" BUILD_PLATFORM
+           "; zero=" BUILD_ZERO
+           "; dontdump=" BUILD_DONTDUMP
+           "; cflags=" LSECO_BUILD_FLAGS;
+}
+
+void secure_memory_zero(void* ptr, size_t size) {
+    if (ptr == NULL || size == 0) {
+        return;
+    }
+    
+    secure_zero(ptr, size);
+}
+
+void secure_buffer_free(void* buffer, size_t size) {
+    if (buffer == NULL || size == 0) {
+        return;
+    }
+    
+    size_t page_size = get_page_size();
+    size_t aligned_size = ((size + page_size - 1) / page_size) * page_size;
+    
+    /* Securely zero memory */
+    secure_zero(buffer, aligned_size);
+    
+    /* Unlock memory */
+    unlock_memory(buffer, aligned_size);
+    
+    /* Free memory */
+#ifdef _WIN32
+    VirtualFree(buffer, 0, MEM_RELEASE);
+#else
+    free(buffer);
+#endif
+}
@@ -15,10 +15,26 @@ extern "C" {
 #define SECURE_ERR_LOCK_FAILED   -3
 #define SECURE_ERR_PROTECT_FAILED -4
 #define SECURE_ERR_INVALID_SIZE  -5
+#define SECURE_ERR_IO            -6
+
+/* XChaCha20-Poly1305 parameter sizes */
+#define SECURE_XCHACHA20_KEY_SIZE   32
+#define SECURE_XCHACHA20_NONCE_SIZE 24
+#define SECURE_POLY1305_TAG_SIZE    16
 
 /* Opaque handle for secure memory */
 typedef struct secure_memory_t secure_memory_t;
 
+/* Custom allocator for secure_memory_create_with_allocator */
+typedef struct secure_allocator_t {
+    /* Return size bytes aligned to alignment and locked in RAM, or NULL */
+    void* (*alloc)(size_t size, size_t alignment, void* ctx);
+    /* Release memory returned by alloc; it has already been zeroed */
+    void (*free)(void* ptr, size_t size, void* ctx);
+    /* Passed to both callbacks */
+    void* ctx;
+} secure_allocator_t;
+
 /**
  * @brief Create a secure memory region
  * 
@@ -30,6 +46,58 @@ typedef struct secure_memory_t secure_memory_t;
  */
 int secure_memory_create(secure_memory_t** handle, size_t size);
 
+/**
+ * @brief Create a secure memory region with a minimum alignment
+ * 
+ * Like secure_memory_create, but the region starts at a multiple of
+ * alignment. Page alignment already covers any alignment up to the page
+ * size; larger alignments are passed to posix_memalign.
+ * 
+ * @param handle Pointer to store the created handle
+ * @param size Size of memory to allocate (must be > 0)
+ * @param alignment Power of two, or 0 for page alignment
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_create_aligned(secure_memory_t** handle, size_t size, size_t alignment);
+
+/**
+ * @brief Create a secure memory region from a custom allocator
+ * 
+ * Like secure_memory_create_aligned, but the region comes from allocator
+ * instead of posix_memalign or VirtualAlloc, e.g. HSM or pre-reserved
+ * memory. The library does not mlock it: the allocator must return memory
+ * that is already locked in RAM, and page-aligned so access can still be
+ * revoked between operations. Regions created by secure_memory_resize use
+ * the same allocator.
+ * 
+ * @param handle Pointer to store the created handle
+ * @param size Size of memory to allocate (must be > 0)
+ * @param alignment Power of two, or 0 for page alignment
+ * @param allocator Allocator callbacks, copied into the handle (alloc and free must not be NULL)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_create_with_allocator(secure_memory_t** handle, size_t size, size_t alignment,
+                                        const secure_allocator_t* allocator);
+
+/**
+ * @brief Create a secure memory region surrounded by guard pages
+ * 
+ * Like secure_memory_create_with_allocator, but if guard_pages is nonzero
+ * one page before and one page after the region stay inaccessible for
+ * its whole lifetime, so a linear overflow out of it faults instead of
+ * silently corrupting neighbouring memory. Guard pages are not locked.
+ * Regions created by secure_memory_resize keep the guards.
+ * 
+ * @param handle Pointer to store the created handle
+ * @param size Size of memory to allocate (must be > 0)
+ * @param alignment Power of two, or 0 for page alignment
+ * @param allocator Allocator callbacks, or NULL for the built-in allocator
+ * @param guard_pages Nonzero to add guard pages
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_create_guarded(secure_memory_t** handle, size_t size, size_t alignment,
+                                 const secure_allocator_t* allocator, int guard_pages);
+
 /**
  * @brief Write data to secure memory
  * 
@@ -54,6 +122,229 @@ int secure_memory_write(secure_memory_t* handle, const void* data, size_t length
  */
 int secure_memory_read(const secure_memory_t* handle, void* buffer, size_t length);
 
+/**
+ * @brief Write data to secure memory at an offset
+ * 
+ * Same as secure_memory_write, but copies into the region starting at offset.
+ * 
+ * @param handle Valid secure memory handle (must not be NULL)
+ * @param offset Byte offset into the region (offset + length must be <= allocated size)
+ * @param data Data to write (must not be NULL)
+ * @param length Length of data (must be > 0)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_write_at(secure_memory_t* handle, size_t offset, const void* data, size_t length);
+
+/**
+ * @brief Read data from secure memory at an offset
+ * 
+ * Same as secure_memory_read, but copies out of the region starting at offset.
+ * 
+ * @param handle Valid secure memory handle (must not be NULL)
+ * @param offset Byte offset into the region (offset + length must be <= allocated size)
+ * @param buffer Output buffer (must not be NULL)
+ * @param length Length to read (must be > 0)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_read_at(const secure_memory_t* handle, size_t offset, void* buffer, size_t length);
+
+/**
+ * @brief Receive data from a socket directly into secure memory
+ * 
+ * Grants READWRITE permission and calls recv() on fd until length bytes
+ * have arrived at offset or the socket reports an error, then revokes
+ * access. Interrupted calls are retried. On a non-blocking socket with no
+ * data pending, returns SECURE_ERR_IO with errno set to EAGAIN, so the
+ * caller can wait for readability and continue at offset + *received.
+ * An orderly shutdown by the peer returns SECURE_ERR_IO with errno 0.
+ * 
+ * @param handle Valid secure memory handle (must not be NULL)
+ * @param offset Byte offset into the region (offset + length must be <= allocated size)
+ * @param fd Connected socket (a SOCKET on Windows)
+ * @param length Number of bytes to receive (must be > 0)
+ * @param received Set to the number of bytes received (must not be NULL)
+ * @return SECURE_SUCCESS once length bytes were received, error code otherwise
+ */
+int secure_memory_recv_at(secure_memory_t* handle, size_t offset, intptr_t fd,
+                          size_t length, size_t* received);
+
+/**
+ * @brief Securely zero a range of secure memory
+ * 
+ * Temporarily grants READWRITE permission, zeros the range, then revokes access.
+ * 
+ * @param handle Valid secure memory handle (must not be NULL)
+ * @param offset Byte offset into the region (offset + length must be <= allocated size)
+ * @param length Number of bytes to zero (must be > 0)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_wipe_at(secure_memory_t* handle, size_t offset, size_t length);
+
+/**
+ * @brief Copy a range from one secure memory region to another
+ * 
+ * Temporarily grants READWRITE permission on both regions, copies the range,
+ * then revokes access. Source and destination may be the same handle, in
+ * which case the ranges may overlap.
+ * 
+ * @param dst Destination handle (must not be NULL)
+ * @param dst_offset Byte offset into dst (dst_offset + length must be <= dst size)
+ * @param src Source handle (must not be NULL)
+ * @param src_offset Byte offset into src (src_offset + length must be <= src size)
+ * @param length Number of bytes to copy (must be > 0)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_copy_at(secure_memory_t* dst, size_t dst_offset,
+                          const secure_memory_t* src, size_t src_offset, size_t length);
+
+/**
+ * @brief Compare the first length bytes of two regions in constant time
+ * 
+ * The running time depends only on length, never on the content.
+ * 
+ * @param a First handle (must not be NULL)
+ * @param b Second handle (must not be NULL, may equal a)
+ * @param length Number of bytes to compare (must be > 0 and <= both sizes)
+ * @param equal Set to 1 if the ranges are equal, 0 otherwise (must not be NULL)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_compare(const secure_memory_t* a, const secure_memory_t* b,
+                          size_t length, int* equal);
+
+/**
+ * @brief Compare the first length bytes of a region with a buffer in constant time
+ * 
+ * Like secure_memory_compare, but against caller memory such as an
+ * expected hash. The running time depends only on length.
+ * 
+ * @param handle Handle to compare (must not be NULL)
+ * @param data Buffer of at least length bytes (must not be NULL)
+ * @param length Number of bytes to compare (must be > 0 and <= size)
+ * @param equal Set to 1 if the ranges are equal, 0 otherwise (must not be NULL)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_compare_bytes(const secure_memory_t* handle, const void* data,
+                                size_t length, int* equal);
+
+/**
+ * @brief Mark which of the first length bytes differ between two regions
+ * 
+ * Sets changed[i] to 1 where the regions differ and 0 where they match,
+ * touching every byte without branching on the content. Only positions
+ * are written, never the values themselves.
+ * 
+ * @param a First handle (must not be NULL)
+ * @param b Second handle (must not be NULL, may equal a)
+ * @param length Number of bytes to compare (must be > 0 and <= both sizes)
+ * @param changed Buffer of at least length bytes receiving the flags (must not be NULL)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_diff(const secure_memory_t* a, const secure_memory_t* b,
+                       size_t length, unsigned char* changed);
+
+/**
+ * @brief Copy the first length bytes of one region into another in constant time
+ * 
+ * Like secure_memory_copy_at with both offsets 0, but copies through
+ * volatile byte accesses followed by a compiler barrier instead of
+ * memmove, so the compiler cannot vectorize, shortcut, or otherwise
+ * specialize the copy on the values being copied. The time taken depends
+ * only on length. dst and src may be the same handle.
+ * 
+ * @param dst Destination handle (must not be NULL)
+ * @param src Source handle (must not be NULL)
+ * @param length Number of bytes to copy (must be > 0 and <= both sizes)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_ct_copy(secure_memory_t* dst, const secure_memory_t* src, size_t length);
+
+/**
+ * @brief XOR the first length bytes of one region into another
+ * 
+ * Temporarily grants READWRITE permission on both regions, sets
+ * dst[i] ^= src[i] for every byte in the range, then revokes access.
+ * Applying the same src twice restores dst. dst and src may be the same
+ * handle, which zeroes the range.
+ * 
+ * @param dst Handle modified in place (must not be NULL)
+ * @param src Mask handle (must not be NULL, may equal dst)
+ * @param length Number of bytes to XOR (must be > 0 and <= both sizes)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_xor(secure_memory_t* dst, const secure_memory_t* src, size_t length);
+
+/**
+ * @brief XOR the first length bytes of a region with a repeating key
+ * 
+ * Temporarily grants READWRITE permission, sets data[i] ^= key[i % key_len]
+ * for every byte in the range, then revokes access. Applying the same key
+ * twice restores the content. This is obfuscation for legacy protocols,
+ * not encryption: a repeating key is trivially recovered from known
+ * plaintext.
+ * 
+ * @param handle Handle modified in place (must not be NULL)
+ * @param key Key bytes (must not be NULL)
+ * @param key_len Key length (must be > 0)
+ * @param length Number of bytes to XOR (must be > 0 and <= size)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_xor_obfuscate(secure_memory_t* handle, const void* key,
+                                size_t key_len, size_t length);
+
+/**
+ * @brief Encrypt the first length bytes of a region with XChaCha20-Poly1305
+ * 
+ * Temporarily grants access on both regions and encrypts the plaintext
+ * under the first 32 bytes of key without additional data, as in
+ * libsodium's crypto_aead_xchacha20poly1305_ietf_encrypt. The subkey,
+ * keystream, and Poly1305 state live on the stack and are zeroed before
+ * returning; only ciphertext is written to out.
+ * 
+ * @param plaintext Handle to encrypt (must not be NULL)
+ * @param length Number of bytes to encrypt (must be > 0 and <= size)
+ * @param key Key handle of at least SECURE_XCHACHA20_KEY_SIZE bytes (must not be NULL, may equal plaintext)
+ * @param nonce SECURE_XCHACHA20_NONCE_SIZE bytes, never reused with the same key (must not be NULL)
+ * @param out Receives length + SECURE_POLY1305_TAG_SIZE bytes: ciphertext, then tag (must not be NULL)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_xchacha20poly1305_seal(const secure_memory_t* plaintext, size_t length,
+                                         const secure_memory_t* key, const void* nonce, void* out);
+
+/**
+ * @brief Resize secure memory in place
+ * 
+ * Allocates a new locked region with the same alignment, copies as much
+ * existing content as fits, then zeroes and frees the old region. The
+ * handle stays valid and refers to the new region. On failure the handle
+ * is left unchanged.
+ * 
+ * @param handle Valid secure memory handle (must not be NULL)
+ * @param new_size New size in bytes (must be > 0)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_resize(secure_memory_t* handle, size_t new_size);
+
+/**
+ * @brief Grant direct access to secure memory
+ * 
+ * Grants READWRITE permission and returns the region's address. The region
+ * stays accessible until secure_memory_release is called, so the caller
+ * must keep that window as short as possible.
+ * 
+ * @param handle Valid secure memory handle (must not be NULL)
+ * @param data Receives the address of the region (must not be NULL)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_acquire(secure_memory_t* handle, void** data);
+
+/**
+ * @brief Revoke access granted by secure_memory_acquire
+ * 
+ * @param handle Valid secure memory handle (must not be NULL)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_memory_release(secure_memory_t* handle);
+
 /**
  * @brief Securely destroy secure memory
  * 
@@ -72,6 +363,49 @@ void secure_memory_destroy(secure_memory_t** handle);
  */
 size_t secure_memory_get_size(const secure_memory_t* handle);
 
+/**
+ * @brief Allocate a locked, readable buffer
+ * 
+ * Allocates page-aligned memory and locks it in RAM like secure_memory_create,
+ * but leaves it READWRITE so callers can access it directly.
+ * 
+ * @param buffer Pointer to store the allocated buffer
+ * @param size Size of memory to allocate (must be > 0)
+ * @return SECURE_SUCCESS on success, error code otherwise
+ */
+int secure_buffer_alloc(void** buffer, size_t size);
+
+/**
+ * @brief Securely free a buffer from secure_buffer_alloc
+ * 
+ * Zeros the buffer, unlocks it, and frees it. Safe to call with NULL buffer.
+ * 
+ * @param buffer Buffer to free
+ * @param size Size passed to secure_buffer_alloc
+ */
+void secure_buffer_free(void* buffer, size_t size);
+
+/**
+ * @brief Describe how the library was built
+ * 
+ * Reports the compiler, platform, zeroing primitive, core dump
+ * exclusion support, and compiler flags, for diagnosing platform issues.
+ * 
+ * @return Static string, never NULL
+ */
+const char* secure_memory_build_info(void);
+
+/**
+ * @brief Zero arbitrary memory in a way the compiler cannot elide
+ * 
+ * Uses the same primitive as secure_memory_destroy (explicit_bzero,
+ * memset_s, or SecureZeroMemory where available).
+ * 
+ * @param ptr Memory to zero (NULL is a no-op)
+ * @param size Number of bytes to zero
+ */
+void secure_memory_zero(void* ptr, size_t size);
+
 #ifdef __cplusplus
 }
 #endif
@@ -2,6 +2,15 @@
 #include <stdio.h>
 #include <string.h>
 #include <assert.h>
+#include <stdint.h>
+#include <stdlib.h>
+#include <errno.h>
+#include <fcntl.h>
+#include <sys/mman.h>
+#include <sys/socket.h>
+#include <sys/wait.h>
+#include <signal.h>
+#include <unistd.h>
 
 #define ANSI_COLOR_GREEN   "\x1b[32m"
 #define ANSI_COLOR_RED     "\x1b[31m"
@@ -15,6 +24,22 @@ void test_version() {
     printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET " (version: %s)\n", version);
 }
 
+void test_build_info() {
+    printf("Testing lseco_build_info()... ");
+    const char* info = lseco_build_info();
+    assert(info != NULL);
+    assert(strstr(info, "compiler=") != NULL);
+    assert(strstr(info, "cflags=") != NULL);
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_sgx_available() {
+    printf("Testing lseco_sgx_available()... ");
+    /* No enclave backend is built in */
+    assert(lseco_sgx_available() == 0);
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
 void test_error_strings() {
     printf("Testing lseco_error_string()... ");
     const char* msg = lseco_error_string(LSECO_SUCCESS);
@@ -184,6 +209,804 @@ void test_binary_data() {
     printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
 }
 
+void test_store_retrieve_at() {
+    printf("Testing lseco_store_at() and lseco_retrieve_at()... ");
+    
+    lseco_handle_t handle = lseco_create(16);
+    assert(handle != NULL);
+    
+    /* Test NULL pointer validation */
+    int result = lseco_store_at(NULL, 0, "test", 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    /* Test bounds: offset + length must fit */
+    result = lseco_store_at(handle, 12, "12345", 5);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_store_at(handle, 17, "1", 1);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test partial writes leave the rest untouched */
+    result = lseco_store(handle, "abcdefgh", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_store_at(handle, 4, "WXYZ1234", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    char buffer[16] = {0};
+    result = lseco_retrieve_at(handle, 2, buffer, 6);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "cdWXYZ", 6) == 0);
+    
+    result = lseco_retrieve_at(handle, 12, buffer, 8);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    lseco_destroy(handle);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_wipe_at() {
+    printf("Testing lseco_wipe_at()... ");
+    
+    lseco_handle_t handle = lseco_create(16);
+    assert(handle != NULL);
+    
+    /* Test NULL and size validation */
+    int result = lseco_wipe_at(NULL, 0, 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_wipe_at(handle, 0, 0);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_wipe_at(handle, 8, 9);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test only the requested range is zeroed */
+    result = lseco_store(handle, "abcdefgh", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_wipe_at(handle, 2, 4);
+    assert(result == LSECO_SUCCESS);
+    
+    char buffer[8];
+    result = lseco_retrieve(handle, buffer, sizeof(buffer));
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "ab\0\0\0\0gh", 8) == 0);
+    
+    lseco_destroy(handle);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_buffer_create_destroy() {
+    printf("Testing lseco_buffer_create() and lseco_buffer_destroy()... ");
+    
+    /* Test invalid size */
+    unsigned char* buffer = lseco_buffer_create(0);
+    assert(buffer == NULL);
+    
+    /* Test valid creation is zero-filled and directly accessible */
+    buffer = lseco_buffer_create(64);
+    assert(buffer != NULL);
+    for (size_t i = 0; i < 64; i++) {
+        assert(buffer[i] == 0);
+    }
+    memcpy(buffer, "secret", 6);
+    assert(memcmp(buffer, "secret", 6) == 0);
+    
+    /* Test retrieving straight into a locked buffer */
+    lseco_handle_t handle = lseco_create(64);
+    assert(handle != NULL);
+    int result = lseco_store(handle, "locked", 6);
+    assert(result == LSECO_SUCCESS);
+    result = lseco_retrieve(handle, buffer, 6);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "locked", 6) == 0);
+    lseco_destroy(handle);
+    
+    lseco_buffer_destroy(buffer, 64);
+    
+    /* Test destroy with NULL (should not crash) */
+    lseco_buffer_destroy(NULL, 64);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_copy_at() {
+    printf("Testing lseco_copy_at()... ");
+    
+    lseco_handle_t src = lseco_create(16);
+    lseco_handle_t dst = lseco_create(8);
+    assert(src != NULL && dst != NULL);
+    
+    /* Test NULL and size validation */
+    int result = lseco_copy_at(NULL, 0, src, 0, 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_copy_at(dst, 0, NULL, 0, 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_copy_at(dst, 0, src, 0, 0);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_copy_at(dst, 4, src, 0, 5);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_copy_at(dst, 0, src, 12, 5);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test copy between storages */
+    result = lseco_store(src, "0123456789abcdef", 16);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_copy_at(dst, 2, src, 10, 6);
+    assert(result == LSECO_SUCCESS);
+    
+    char buffer[16];
+    result = lseco_retrieve_at(dst, 2, buffer, 6);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "abcdef", 6) == 0);
+    
+    /* Test overlapping copy within one storage */
+    result = lseco_copy_at(src, 2, src, 0, 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_retrieve(src, buffer, 16);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "0101234567abcdef", 16) == 0);
+    
+    lseco_destroy(src);
+    lseco_destroy(dst);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_compare() {
+    printf("Testing lseco_compare()... ");
+    
+    lseco_handle_t a = lseco_create(16);
+    lseco_handle_t b = lseco_create(8);
+    assert(a != NULL && b != NULL);
+    
+    /* Test NULL and size validation */
+    int equal = -1;
+    int result = lseco_compare(NULL, b, 4, &equal);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_compare(a, b, 4, NULL);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_compare(a, b, 9, &equal);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test equal and different content */
+    result = lseco_store(a, "password", 8);
+    assert(result == LSECO_SUCCESS);
+    result = lseco_store(b, "password", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_compare(a, b, 8, &equal);
+    assert(result == LSECO_SUCCESS);
+    assert(equal == 1);
+    
+    result = lseco_store(b, "passworD", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_compare(a, b, 8, &equal);
+    assert(result == LSECO_SUCCESS);
+    assert(equal == 0);
+    
+    /* Test comparing a handle with itself */
+    result = lseco_compare(a, a, 16, &equal);
+    assert(result == LSECO_SUCCESS);
+    assert(equal == 1);
+    
+    lseco_destroy(a);
+    lseco_destroy(b);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_compare_bytes() {
+    printf("Testing lseco_compare_bytes()... ");
+    
+    lseco_handle_t handle = lseco_create(8);
+    assert(handle != NULL);
+    
+    /* Test NULL and size validation */
+    int equal = -1;
+    int result = lseco_compare_bytes(NULL, "password", 8, &equal);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_compare_bytes(handle, NULL, 8, &equal);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_compare_bytes(handle, "password", 8, NULL);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_compare_bytes(handle, "password", 0, &equal);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_compare_bytes(handle, "password!", 9, &equal);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test equal and different content */
+    result = lseco_store(handle, "password", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_compare_bytes(handle, "password", 8, &equal);
+    assert(result == LSECO_SUCCESS);
+    assert(equal == 1);
+    
+    result = lseco_compare_bytes(handle, "passworD", 8, &equal);
+    assert(result == LSECO_SUCCESS);
+    assert(equal == 0);
+    
+    lseco_destroy(handle);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_diff() {
+    printf("Testing lseco_diff()... ");
+    
+    lseco_handle_t a = lseco_create(8);
+    lseco_handle_t b = lseco_create(8);
+    assert(a != NULL && b != NULL);
+    
+    /* Test NULL and size validation */
+    unsigned char changed[8];
+    int result = lseco_diff(NULL, b, 8, changed);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_diff(a, NULL, 8, changed);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_diff(a, b, 8, NULL);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_diff(a, b, 0, changed);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_diff(a, b, 9, changed);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test only differing positions are flagged */
+    result = lseco_store(a, "abcdefgh", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_store(b, "abXdefYZ", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_diff(a, b, 8, changed);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(changed, "\0\0\1\0\0\0\1\1", 8) == 0);
+    
+    /* Test a handle against itself */
+    result = lseco_diff(a, a, 8, changed);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(changed, "\0\0\0\0\0\0\0\0", 8) == 0);
+    
+    lseco_destroy(a);
+    lseco_destroy(b);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_ct_copy() {
+    printf("Testing lseco_ct_copy()... ");
+    
+    lseco_handle_t dst = lseco_create(8);
+    lseco_handle_t src = lseco_create(4);
+    assert(dst != NULL && src != NULL);
+    
+    /* Test NULL and size validation */
+    int result = lseco_ct_copy(NULL, src, 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_ct_copy(dst, NULL, 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_ct_copy(dst, src, 0);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_ct_copy(dst, src, 5);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test the copy overwrites only the first length bytes */
+    result = lseco_store(dst, "abcdefgh", 8);
+    assert(result == LSECO_SUCCESS);
+    result = lseco_store(src, "wxyz", 4);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_ct_copy(dst, src, 4);
+    assert(result == LSECO_SUCCESS);
+    
+    char buffer[8];
+    result = lseco_retrieve(dst, buffer, 8);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "wxyzefgh", 8) == 0);
+    
+    lseco_destroy(dst);
+    lseco_destroy(src);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_xor() {
+    printf("Testing lseco_xor()... ");
+    
+    lseco_handle_t secret = lseco_create(8);
+    lseco_handle_t mask = lseco_create(4);
+    assert(secret != NULL && mask != NULL);
+    
+    /* Test NULL and size validation */
+    int result = lseco_xor(NULL, mask, 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_xor(secret, NULL, 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_xor(secret, mask, 0);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_xor(secret, mask, 5);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test masking and unmasking */
+    result = lseco_store(secret, "abcdefgh", 8);
+    assert(result == LSECO_SUCCESS);
+    result = lseco_store(mask, "\x01\x02\x03\x04", 4);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_xor(secret, mask, 4);
+    assert(result == LSECO_SUCCESS);
+    
+    char buffer[8];
+    result = lseco_retrieve(secret, buffer, 8);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "````efgh", 8) == 0);
+    
+    result = lseco_xor(secret, mask, 4);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_retrieve(secret, buffer, 8);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "abcdefgh", 8) == 0);
+    
+    /* Test XOR with itself zeroes the range */
+    result = lseco_xor(secret, secret, 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_retrieve(secret, buffer, 8);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "\0\0\0\0\0\0\0\0", 8) == 0);
+    
+    lseco_destroy(secret);
+    lseco_destroy(mask);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_xor_obfuscate() {
+    printf("Testing lseco_xor_obfuscate()... ");
+    
+    lseco_handle_t handle = lseco_create(8);
+    assert(handle != NULL);
+    
+    /* Test NULL and size validation */
+    int result = lseco_xor_obfuscate(NULL, "\x01", 1, 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_xor_obfuscate(handle, NULL, 1, 4);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_xor_obfuscate(handle, "\x01", 0, 4);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_xor_obfuscate(handle, "\x01", 1, 0);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_xor_obfuscate(handle, "\x01", 1, 9);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test the key cycles and a second pass restores the content */
+    result = lseco_store(handle, "abcdefgh", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_xor_obfuscate(handle, "\x01\x02\x03", 3, 7);
+    assert(result == LSECO_SUCCESS);
+    
+    char buffer[8];
+    result = lseco_retrieve(handle, buffer, 8);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "```egefh", 8) == 0);
+    
+    result = lseco_xor_obfuscate(handle, "\x01\x02\x03", 3, 7);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_retrieve(handle, buffer, 8);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "abcdefgh", 8) == 0);
+    
+    lseco_destroy(handle);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_xchacha20poly1305_seal() {
+    printf("Testing lseco_xchacha20poly1305_seal()... ");
+    
+    static const char plaintext[] = "Ladies and Gentlemen of the class of '99: "
+        "If I could offer you only one tip for the future, sunscreen would be it.";
+    static const unsigned char expected[] =
+        "\xbd\x6d\x17\x9d\x3e\x83\xd4\x3b\x95\x76\x57\x94\x93\xc0\xe9\x39"
+        "\x57\x2a\x17\x00\x25\x2b\xfa\xcc\xbe\xd2\x90\x2c\x21\x39\x6c\xbb"
+        "\x73\x1c\x7f\x1b\x0b\x4a\xa6\x44\x0b\xf3\xa8\x2f\x4e\xda\x7e\x39"
+        "\xae\x64\xc6\x70\x8c\x54\xc2\x16\xcb\x96\xb7\x2e\x12\x13\xb4\x52"
+        "\x2f\x8c\x9b\xa4\x0d\xb5\xd9\x45\xb1\x1b\x69\xb9\x82\xc1\xbb\x9e"
+        "\x3f\x3f\xac\x2b\xc3\x69\x48\x8f\x76\xb2\x38\x35\x65\xd3\xff\xf9"
+        "\x21\xf9\x66\x4c\x97\x63\x7d\xa9\x76\x88\x12\xf6\x15\xc6\x8b\x13"
+        "\xb5\x2e\xf7\xe6\x2e\xfb\xf4\x50\x89\xdb\x18\xf9\xc8\xa3\xf0\xe4"
+        "\x1e\x5f";
+    const size_t length = sizeof(plaintext) - 1;
+    
+    lseco_handle_t secret = lseco_create(length);
+    lseco_handle_t key = lseco_create(LSECO_XCHACHA20_KEY_SIZE);
+    assert(secret != NULL && key != NULL);
+    
+    unsigned char key_bytes[LSECO_XCHACHA20_KEY_SIZE];
+    unsigned char nonce[LSECO_XCHACHA20_NONCE_SIZE];
+    for (size_t i = 0; i < sizeof(key_bytes); i++) {
+        key_bytes[i] = (unsigned char)(0x80 + i);
+    }
+    for (size_t i = 0; i < sizeof(nonce); i++) {
+        nonce[i] = (unsigned char)(0x40 + i);
+    }
+    unsigned char out[sizeof(plaintext) - 1 + LSECO_POLY1305_TAG_SIZE];
+    
+    /* Test NULL and size validation */
+    int result = lseco_xchacha20poly1305_seal(NULL, length, key, nonce, out);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_xchacha20poly1305_seal(secret, length, NULL, nonce, out);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_xchacha20poly1305_seal(secret, length, key, NULL, out);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_xchacha20poly1305_seal(secret, length, key, nonce, NULL);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_xchacha20poly1305_seal(secret, 0, key, nonce, out);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    result = lseco_xchacha20poly1305_seal(secret, length + 1, key, nonce, out);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    lseco_handle_t short_key = lseco_create(LSECO_XCHACHA20_KEY_SIZE - 1);
+    assert(short_key != NULL);
+    result = lseco_xchacha20poly1305_seal(secret, length, short_key, nonce, out);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    lseco_destroy(short_key);
+    
+    /* Test against a reference ciphertext spanning partial blocks */
+    result = lseco_store(secret, plaintext, length);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_store(key, key_bytes, sizeof(key_bytes));
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_xchacha20poly1305_seal(secret, length, key, nonce, out);
+    assert(result == LSECO_SUCCESS);
+    assert(sizeof(expected) - 1 == sizeof(out));
+    assert(memcmp(out, expected, sizeof(out)) == 0);
+    
+    /* Test the plaintext is left intact */
+    char buffer[sizeof(plaintext) - 1];
+    result = lseco_retrieve(secret, buffer, length);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, plaintext, length) == 0);
+    
+    lseco_destroy(secret);
+    lseco_destroy(key);
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_resize() {
+    printf("Testing lseco_resize()... ");
+    
+    lseco_handle_t handle = lseco_create(8);
+    assert(handle != NULL);
+    
+    /* Test validation */
+    int result = lseco_resize(NULL, 16);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_resize(handle, 0);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test growing keeps content */
+    result = lseco_store(handle, "12345678", 8);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_resize(handle, 8192);
+    assert(result == LSECO_SUCCESS);
+    assert(lseco_get_size(handle) == 8192);
+    
+    char buffer[8];
+    result = lseco_retrieve(handle, buffer, sizeof(buffer));
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "12345678", 8) == 0);
+    
+    /* Test shrinking truncates */
+    result = lseco_resize(handle, 4);
+    assert(result == LSECO_SUCCESS);
+    assert(lseco_get_size(handle) == 4);
+    
+    result = lseco_retrieve(handle, buffer, 4);
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "1234", 4) == 0);
+    
+    result = lseco_retrieve(handle, buffer, 8);
+    assert(result == LSECO_ERR_INVALID_SIZE);
+    
+    lseco_destroy(handle);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_secure_zero() {
+    printf("Testing lseco_secure_zero()... ");
+    
+    /* Test NULL and empty input are no-ops */
+    lseco_secure_zero(NULL, 16);
+    
+    unsigned char data[8] = {1, 2, 3, 4, 5, 6, 7, 8};
+    lseco_secure_zero(data, 0);
+    assert(data[0] == 1);
+    
+    /* Test partial and full zeroing */
+    lseco_secure_zero(data, 4);
+    assert(data[3] == 0 && data[4] == 5);
+    
+    lseco_secure_zero(data, sizeof(data));
+    for (size_t i = 0; i < sizeof(data); i++) {
+        assert(data[i] == 0);
+    }
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+/* Test allocator: page-aligned malloc + mlock, counting calls */
+static int test_alloc_calls;
+static int test_free_calls;
+
+static void* test_alloc(size_t size, size_t alignment, void* ctx) {
+    assert(ctx == &test_alloc_calls);
+    void* ptr = NULL;
+    if (posix_memalign(&ptr, alignment, size) != 0) {
+        return NULL;
+    }
+    mlock(ptr, size);
+    test_alloc_calls++;
+    return ptr;
+}
+
+static void test_free(void* ptr, size_t size, void* ctx) {
+    assert(ctx == &test_alloc_calls);
+    munlock(ptr, size);
+    free(ptr);
+    test_free_calls++;
+}
+
+void test_create_with_allocator() {
+    printf("Testing lseco_create_with_allocator()... ");
+    
+    lseco_allocator_t allocator = {test_alloc, test_free, &test_alloc_calls};
+    lseco_allocator_t missing_free = {test_alloc, NULL, NULL};
+    
+    /* Test NULL allocator, missing callbacks, and invalid parameters */
+    assert(lseco_create_with_allocator(64, 0, NULL) == NULL);
+    assert(lseco_create_with_allocator(64, 0, &missing_free) == NULL);
+    assert(lseco_create_with_allocator(0, 0, &allocator) == NULL);
+    assert(lseco_create_with_allocator(64, 48, &allocator) == NULL);
+    assert(test_alloc_calls == 0);
+    
+    /* Test store/retrieve through allocator-backed memory */
+    lseco_handle_t handle = lseco_create_with_allocator(64, 0, &allocator);
+    assert(handle != NULL);
+    assert(test_alloc_calls == 1);
+    
+    const char* data = "allocator";
+    assert(lseco_store(handle, data, 9) == LSECO_SUCCESS);
+    
+    /* Test resize allocates from, and frees to, the same allocator */
+    assert(lseco_resize(handle, 8192) == LSECO_SUCCESS);
+    assert(test_alloc_calls == 2 && test_free_calls == 1);
+    
+    char out[9];
+    assert(lseco_retrieve(handle, out, sizeof(out)) == LSECO_SUCCESS);
+    assert(memcmp(out, data, 9) == 0);
+    
+    lseco_destroy(handle);
+    assert(test_free_calls == 2);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+/* Write one byte just past the page-rounded end of a mapped storage */
+static void overflow_into_guard(lseco_handle_t handle, size_t size) {
+    void* data = NULL;
+    assert(lseco_acquire(handle, &data) == LSECO_SUCCESS);
+    size_t page = (size_t)sysconf(_SC_PAGESIZE);
+    size_t aligned = ((size + page - 1) / page) * page;
+    ((volatile unsigned char*)data)[aligned] = 0x41;
+}
+
+void test_create_guarded() {
+    printf("Testing lseco_create_guarded()... ");
+    
+    lseco_allocator_t missing_free = {test_alloc, NULL, NULL};
+    
+    /* Test invalid parameters */
+    assert(lseco_create_guarded(0, 0, NULL) == NULL);
+    assert(lseco_create_guarded(64, 48, NULL) == NULL);
+    assert(lseco_create_guarded(64, 0, &missing_free) == NULL);
+    
+    /* Test store/retrieve, including across resize */
+    lseco_handle_t handle = lseco_create_guarded(64, 0, NULL);
+    assert(handle != NULL);
+    assert(lseco_store(handle, "guarded", 7) == LSECO_SUCCESS);
+    assert(lseco_resize(handle, 8192) == LSECO_SUCCESS);
+    
+    char out[7];
+    assert(lseco_retrieve(handle, out, sizeof(out)) == LSECO_SUCCESS);
+    assert(memcmp(out, "guarded", 7) == 0);
+    
+    /* Test an overflow past the region faults, even while it is mapped */
+    fflush(stdout);
+    pid_t pid = fork();
+    assert(pid >= 0);
+    if (pid == 0) {
+        overflow_into_guard(handle, 8192);
+        _exit(0);
+    }
+    int status = 0;
+    assert(waitpid(pid, &status, 0) == pid);
+    assert(WIFSIGNALED(status) && WTERMSIG(status) == SIGSEGV);
+    lseco_destroy(handle);
+    
+    /* Test guard pages with a custom allocator and a large alignment */
+    lseco_allocator_t allocator = {test_alloc, test_free, &test_alloc_calls};
+    int allocs = test_alloc_calls, frees = test_free_calls;
+    handle = lseco_create_guarded(32, 65536, &allocator);
+    assert(handle != NULL);
+    assert(test_alloc_calls == allocs + 1);
+    void* data = NULL;
+    assert(lseco_acquire(handle, &data) == LSECO_SUCCESS);
+    assert((uintptr_t)data % 65536 == 0);
+    assert(lseco_release(handle) == LSECO_SUCCESS);
+    lseco_destroy(handle);
+    assert(test_free_calls == frees + 1);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_recv_at() {
+    printf("Testing lseco_recv_at()... ");
+    
+    lseco_handle_t handle = lseco_create(16);
+    assert(handle != NULL);
+    
+    int fds[2];
+    assert(socketpair(AF_UNIX, SOCK_STREAM, 0, fds) == 0);
+    size_t received = 0;
+    
+    /* Test NULL pointer and invalid ranges */
+    assert(lseco_recv_at(NULL, 0, fds[0], 4, &received) == LSECO_ERR_NULL_PTR);
+    assert(lseco_recv_at(handle, 0, fds[0], 4, NULL) == LSECO_ERR_NULL_PTR);
+    assert(lseco_recv_at(handle, 0, fds[0], 0, &received) == LSECO_ERR_INVALID_SIZE);
+    assert(lseco_recv_at(handle, 12, fds[0], 8, &received) == LSECO_ERR_INVALID_SIZE);
+    
+    /* Test receiving at an offset */
+    assert(write(fds[1], "secretkey", 9) == 9);
+    assert(lseco_recv_at(handle, 2, fds[0], 6, &received) == LSECO_SUCCESS);
+    assert(received == 6);
+    
+    /* Test a non-blocking socket running dry */
+    fcntl(fds[0], F_SETFL, fcntl(fds[0], F_GETFL) | O_NONBLOCK);
+    assert(lseco_recv_at(handle, 8, fds[0], 8, &received) == LSECO_ERR_IO);
+    assert(errno == EAGAIN || errno == EWOULDBLOCK);
+    assert(received == 3);
+    
+    /* Test peer shutdown */
+    close(fds[1]);
+    assert(lseco_recv_at(handle, 11, fds[0], 4, &received) == LSECO_ERR_IO);
+    assert(errno == 0);
+    assert(received == 0);
+    close(fds[0]);
+    
+    char out[9];
+    assert(lseco_retrieve_at(handle, 2, out, sizeof(out)) == LSECO_SUCCESS);
+    assert(memcmp(out, "secretkey", 9) == 0);
+    
+    lseco_destroy(handle);
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_create_aligned() {
+    printf("Testing lseco_create_aligned()... ");
+    
+    /* Test invalid alignment and size */
+    assert(lseco_create_aligned(64, 48) == NULL);
+    assert(lseco_create_aligned(0, 64) == NULL);
+    
+    /* Test small, page, and larger-than-page alignments */
+    size_t alignments[] = {0, 16, 64, 4096, 1 << 16};
+    for (size_t i = 0; i < sizeof(alignments) / sizeof(alignments[0]); i++) {
+        lseco_handle_t handle = lseco_create_aligned(64, alignments[i]);
+        assert(handle != NULL);
+        
+        void* data = NULL;
+        int result = lseco_acquire(handle, &data);
+        assert(result == LSECO_SUCCESS);
+        if (alignments[i] != 0) {
+            assert(((uintptr_t)data & (alignments[i] - 1)) == 0);
+        }
+        result = lseco_release(handle);
+        assert(result == LSECO_SUCCESS);
+        
+        result = lseco_store(handle, "aligned", 7);
+        assert(result == LSECO_SUCCESS);
+        
+        lseco_destroy(handle);
+    }
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
+void test_acquire_release() {
+    printf("Testing lseco_acquire() and lseco_release()... ");
+    
+    lseco_handle_t handle = lseco_create(16);
+    assert(handle != NULL);
+    
+    /* Test NULL pointer validation */
+    void* data = NULL;
+    int result = lseco_acquire(NULL, &data);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_acquire(handle, NULL);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    result = lseco_release(NULL);
+    assert(result == LSECO_ERR_NULL_PTR);
+    
+    /* Test in-place access sees stored data and persists edits */
+    result = lseco_store(handle, "abcd", 4);
+    assert(result == LSECO_SUCCESS);
+    
+    result = lseco_acquire(handle, &data);
+    assert(result == LSECO_SUCCESS);
+    assert(data != NULL);
+    assert(memcmp(data, "abcd", 4) == 0);
+    ((unsigned char*)data)[0] = 'X';
+    
+    result = lseco_release(handle);
+    assert(result == LSECO_SUCCESS);
+    
+    char buffer[4];
+    result = lseco_retrieve(handle, buffer, sizeof(buffer));
+    assert(result == LSECO_SUCCESS);
+    assert(memcmp(buffer, "Xbcd", 4) == 0);
+    
+    lseco_destroy(handle);
+    
+    printf(ANSI_COLOR_GREEN "PASS" ANSI_COLOR_RESET "\n");
+}
+
 int main() {
     printf("\n");
     printf("==============================================\n");
@@ -191,12 +1014,32 @@ int main() {
     printf("==============================================\n\n");
     
     test_version();
+    test_build_info();
+    test_sgx_available();
     test_error_strings();
     test_create_destroy();
     test_store_retrieve();
     test_size_limits();
     test_multiple_operations();
     test_binary_data();
+    test_store_retrieve_at();
+    test_wipe_at();
+    test_buffer_create_destroy();
+    test_copy_at();
+    test_compare();
+    test_compare_bytes();
+    test_diff();
+    test_ct_copy();
+    test_xor();
+    test_xor_obfuscate();
+    test_xchacha20poly1305_seal();
+    test_resize();
+    test_acquire_release();
+    test_create_aligned();
+    test_secure_zero();
+    test_recv_at();
+    test_create_with_allocator();
+    test_create_guarded();
     
     printf("\n");
     printf(ANSI_COLOR_GREEN "All tests passed! ✓" ANSI_COLOR_RESET "\n\n");